@@ -0,0 +1,231 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// clock.go implements parsing of time control descriptions.
+
+package engine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ClockMode represents how the clock handles the increment and the delay.
+type ClockMode int
+
+const (
+	// FischerClock adds the increment after each move.
+	FischerClock ClockMode = iota
+	// DelayClock (US or simple delay) starts counting down only after the delay.
+	DelayClock
+	// BronsteinClock adds back the time used, but at most the delay, after each move.
+	BronsteinClock
+	// HourglassClock adds the time used by one side to the other side.
+	HourglassClock
+)
+
+var clockModeNames = [...]string{"fischer", "delay", "bronstein", "hourglass"}
+
+func (m ClockMode) String() string {
+	if 0 <= m && int(m) < len(clockModeNames) {
+		return clockModeNames[m]
+	}
+	return fmt.Sprintf("ClockMode(%d)", int(m))
+}
+
+// ParseClockMode parses the name of a clock mode as returned by ClockMode.String.
+func ParseClockMode(s string) (ClockMode, error) {
+	for i, name := range clockModeNames {
+		if strings.EqualFold(s, name) {
+			return ClockMode(i), nil
+		}
+	}
+	return FischerClock, fmt.Errorf("unknown clock mode %s", s)
+}
+
+// ClockPeriod is a period of a game's time control.
+type ClockPeriod struct {
+	Moves int32         // number of moves in this period, 0 if the period lasts until the end of the game
+	Time  time.Duration // time for the period
+	Inc   time.Duration // increment added after each move
+	Delay time.Duration // delay for DelayClock and BronsteinClock
+	Mode  ClockMode     // how the increment and delay are handled
+}
+
+// String returns the period in the format understood by ParseTimeControl.
+func (p ClockPeriod) String() string {
+	s := ""
+	if p.Mode == HourglassClock {
+		s += "*"
+	}
+	if p.Moves != 0 {
+		s += strconv.Itoa(int(p.Moves)) + "/"
+	}
+	s += formatSeconds(p.Time)
+	if p.Inc != 0 {
+		s += "+" + formatSeconds(p.Inc)
+	}
+	switch p.Mode {
+	case DelayClock:
+		s += " d" + formatSeconds(p.Delay)
+	case BronsteinClock:
+		s += " b" + formatSeconds(p.Delay)
+	}
+	return s
+}
+
+// FormatTimeControl formats periods in the format understood by ParseTimeControl.
+func FormatTimeControl(periods []ClockPeriod) string {
+	if len(periods) == 0 {
+		return "-"
+	}
+	s := make([]string, len(periods))
+	for i, p := range periods {
+		s[i] = p.String()
+	}
+	return strings.Join(s, ":")
+}
+
+// ParseTimeControl parses a time control description.
+//
+// The description is a list of periods separated by colons, similar to
+// the PGN TimeControl tag. Each period has the format
+//
+//	[*][moves/]time[+inc][ dDELAY| bDELAY]
+//
+// where time, inc and delay are in seconds. "*" means hourglass,
+// "d" is a simple (US) delay and "b" is a Bronstein delay. The time can
+// also be given in minutes as G/minutes or SD/minutes and the delay
+// can be separated by a semicolon. "-" means no time control.
+//
+// Examples: 40/5400+30, 40/7200:3600, G/90 d5, G/90;d5, 300+2, *180.
+func ParseTimeControl(s string) ([]ClockPeriod, error) {
+	s = strings.TrimSpace(s)
+	if s == "-" || s == "" {
+		return nil, nil
+	}
+
+	var periods []ClockPeriod
+	for _, str := range strings.Split(s, ":") {
+		p, err := parseClockPeriod(str)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time control %q: %v", s, err)
+		}
+		periods = append(periods, p)
+	}
+	return periods, nil
+}
+
+// parseClockPeriod parses a single period of a time control.
+func parseClockPeriod(s string) (ClockPeriod, error) {
+	var p ClockPeriod
+	s = strings.TrimSpace(s)
+
+	// Delay.
+	if i := strings.LastIndexAny(s, " ;"); i >= 0 {
+		delay := strings.TrimSpace(s[i+1:])
+		s = strings.TrimSpace(s[:i])
+		if len(delay) < 2 {
+			return p, fmt.Errorf("invalid delay %q", delay)
+		}
+		switch delay[0] {
+		case 'd', 'D':
+			p.Mode = DelayClock
+		case 'b', 'B':
+			p.Mode = BronsteinClock
+		default:
+			return p, fmt.Errorf("invalid delay %q", delay)
+		}
+		var err error
+		if p.Delay, err = parseSeconds(delay[1:]); err != nil {
+			return p, err
+		}
+	}
+
+	// Hourglass.
+	if strings.HasPrefix(s, "*") {
+		if p.Mode != FischerClock {
+			return p, fmt.Errorf("hourglass cannot have a delay")
+		}
+		p.Mode = HourglassClock
+		s = s[1:]
+	}
+
+	// Increment.
+	if i := strings.Index(s, "+"); i >= 0 {
+		var err error
+		if p.Inc, err = parseSeconds(s[i+1:]); err != nil {
+			return p, err
+		}
+		s = s[:i]
+	}
+
+	// Moves and time.
+	upper := strings.ToUpper(s)
+	if strings.HasPrefix(upper, "G/") || strings.HasPrefix(upper, "SD/") {
+		minutes, err := strconv.ParseFloat(s[strings.Index(s, "/")+1:], 64)
+		if err != nil || minutes <= 0 {
+			return p, fmt.Errorf("invalid minutes %q", s)
+		}
+		p.Time = time.Duration(minutes * float64(time.Minute))
+		return p, nil
+	}
+	if i := strings.Index(s, "/"); i >= 0 {
+		moves, err := strconv.Atoi(s[:i])
+		if err != nil || moves <= 0 {
+			return p, fmt.Errorf("invalid number of moves %q", s[:i])
+		}
+		p.Moves = int32(moves)
+		s = s[i+1:]
+	}
+	var err error
+	if p.Time, err = parseSeconds(s); err != nil {
+		return p, err
+	}
+	if p.Time <= 0 {
+		return p, fmt.Errorf("time must be positive")
+	}
+	return p, nil
+}
+
+// CurrentClockPeriod returns the period in which move number moveNumber
+// (starting at 1) is played and the number of moves remaining in that
+// period including the current move. If the current period lasts until
+// the end of the game then movesToGo is 0.
+// The last period with a move limit is repeated until the end of the game.
+func CurrentClockPeriod(periods []ClockPeriod, moveNumber int) (p ClockPeriod, movesToGo int32, ok bool) {
+	if len(periods) == 0 {
+		return ClockPeriod{}, 0, false
+	}
+	n := int32(moveNumber - 1) // moves played
+	for i := 0; ; i++ {
+		if i >= len(periods) {
+			i = len(periods) - 1
+		}
+		p = periods[i]
+		if p.Moves == 0 {
+			return p, 0, true
+		}
+		if n < p.Moves {
+			return p, p.Moves - n, true
+		}
+		n -= p.Moves
+	}
+}
+
+// parseSeconds parses a non-negative number of seconds.
+func parseSeconds(s string) (time.Duration, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("invalid number of seconds %q", s)
+	}
+	return time.Duration(f * float64(time.Second)), nil
+}
+
+// formatSeconds formats d as a number of seconds.
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+}
@@ -0,0 +1,112 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package engine
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	. "bitbucket.org/zurichess/board"
+)
+
+func TestParseTimeControl(t *testing.T) {
+	data := []struct {
+		in      string
+		periods []ClockPeriod
+		out     string
+	}{
+		{"-", nil, "-"},
+		{"300", []ClockPeriod{{Time: 300 * time.Second}}, "300"},
+		{"300+2", []ClockPeriod{{Time: 300 * time.Second, Inc: 2 * time.Second}}, "300+2"},
+		{"40/5400+30", []ClockPeriod{{Moves: 40, Time: 5400 * time.Second, Inc: 30 * time.Second}}, "40/5400+30"},
+		{"40/7200:3600", []ClockPeriod{
+			{Moves: 40, Time: 7200 * time.Second},
+			{Time: 3600 * time.Second},
+		}, "40/7200:3600"},
+		{"G/90 d5", []ClockPeriod{{Time: 90 * time.Minute, Delay: 5 * time.Second, Mode: DelayClock}}, "5400 d5"},
+		{"G/90;d5", []ClockPeriod{{Time: 90 * time.Minute, Delay: 5 * time.Second, Mode: DelayClock}}, "5400 d5"},
+		{"SD/30 b10", []ClockPeriod{{Time: 30 * time.Minute, Delay: 10 * time.Second, Mode: BronsteinClock}}, "1800 b10"},
+		{"40/120 d5:G/60 d5", []ClockPeriod{
+			{Moves: 40, Time: 120 * time.Second, Delay: 5 * time.Second, Mode: DelayClock},
+			{Time: 60 * time.Minute, Delay: 5 * time.Second, Mode: DelayClock},
+		}, "40/120 d5:3600 d5"},
+		{"*180", []ClockPeriod{{Time: 180 * time.Second, Mode: HourglassClock}}, "*180"},
+		{"0.5+0.1", []ClockPeriod{{Time: 500 * time.Millisecond, Inc: 100 * time.Millisecond}}, "0.5+0.1"},
+	}
+
+	for i, d := range data {
+		periods, err := ParseTimeControl(d.in)
+		if err != nil {
+			t.Errorf("#%d %s: got error %v", i, d.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(periods, d.periods) {
+			t.Errorf("#%d %s: got %+v, wanted %+v", i, d.in, periods, d.periods)
+		}
+		if got := FormatTimeControl(periods); got != d.out {
+			t.Errorf("#%d %s: formatted as %s, wanted %s", i, d.in, got, d.out)
+		}
+	}
+}
+
+func TestParseTimeControlErrors(t *testing.T) {
+	for _, s := range []string{"x", "40/", "/300", "0", "-5", "300+x", "G/90 x5", "G/90 d", "*180 d5", "40/300+"} {
+		if _, err := ParseTimeControl(s); err == nil {
+			t.Errorf("%s: expected error", s)
+		}
+	}
+}
+
+func TestCurrentClockPeriod(t *testing.T) {
+	periods, _ := ParseTimeControl("40/7200:20/3600:900")
+	data := []struct {
+		moveNumber int
+		period     int
+		movesToGo  int32
+	}{
+		{1, 0, 40},
+		{40, 0, 1},
+		{41, 1, 20},
+		{60, 1, 1},
+		{61, 2, 0},
+		{200, 2, 0},
+	}
+	for _, d := range data {
+		p, movesToGo, ok := CurrentClockPeriod(periods, d.moveNumber)
+		if !ok || p != periods[d.period] || movesToGo != d.movesToGo {
+			t.Errorf("move %d: got %v %d, wanted %v %d", d.moveNumber, p, movesToGo, periods[d.period], d.movesToGo)
+		}
+	}
+
+	// The last period is repeated.
+	periods, _ = ParseTimeControl("40/5400")
+	if _, movesToGo, _ := CurrentClockPeriod(periods, 45); movesToGo != 36 {
+		t.Errorf("got %d moves to go, wanted 36", movesToGo)
+	}
+}
+
+func TestThinkingTimeClockMode(t *testing.T) {
+	pos, _ := PositionFromFEN(FENStartPos)
+	thinkingTime := func(mode ClockMode) time.Duration {
+		tc := NewTimeControl(pos, false)
+		tc.WTime, tc.BTime = 60*time.Second, 60*time.Second
+		tc.WDelay, tc.BDelay = 5*time.Second, 5*time.Second
+		tc.Mode = mode
+		tc.Start(false)
+		return tc.searchTime
+	}
+
+	fischer := thinkingTime(FischerClock)
+	if delay := thinkingTime(DelayClock); delay != fischer+5*time.Second {
+		t.Errorf("got delay thinking time %v, wanted %v", delay, fischer+5*time.Second)
+	}
+	if bronstein := thinkingTime(BronsteinClock); bronstein < 5*time.Second {
+		t.Errorf("got bronstein thinking time %v, wanted at least the delay", bronstein)
+	}
+	if hourglass := thinkingTime(HourglassClock); hourglass >= fischer {
+		t.Errorf("got hourglass thinking time %v, wanted less than %v", hourglass, fischer)
+	}
+}
@@ -0,0 +1,397 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// endgame.go implements the knowledge of specific endgames.
+//
+// The linear evaluation cannot tell a won endgame from a drawn one
+// with the same material, so a few endgames are recognized and their
+// evaluation is scaled down, for drawish endgames, or given a bonus,
+// for won endgames that need a specific plan.
+//
+// KPK is solved exactly by a bitbase generated by retrograde analysis
+// the first time it is needed.
+
+package engine
+
+import (
+	"sync"
+
+	. "bitbucket.org/zurichess/board"
+)
+
+// Scale factors of the evaluation of the known endgames.
+const (
+	scaleNormal          = 128 // evaluation is not scaled
+	scaleDraw            = 0   // known draw
+	scaleHard            = 16  // material advantage is hard to convert
+	scaleOppositeBishops = 64  // pure opposite coloured bishops
+)
+
+// Bonuses, in centipawns, of the known won endgames.
+const (
+	kpkWinBonus  = 300
+	kbnkWinScore = 600
+)
+
+// mayBeKnownEndgame returns true if pos can be one of the known endgames.
+func mayBeKnownEndgame(pos *Position) bool {
+	// With more than four pieces only the bishop endgames are recognized.
+	all := pos.ByColor(White) | pos.ByColor(Black)
+	if all.Count() > 4 {
+		others := pos.ByFigure(Knight) | pos.ByFigure(Rook) | pos.ByFigure(Queen)
+		if others != 0 || pos.ByFigure(Bishop) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// evaluateEndgame recognizes the known endgames and sets
+// the scale and the bonus of e accordingly.
+func evaluateEndgame(pos *Position, e *Eval) {
+	if !mayBeKnownEndgame(pos) {
+		return
+	}
+
+	for _, strong := range []Color{White, Black} {
+		weak := strong.Opposite()
+		sm := materialOf(pos, strong)
+		wm := materialOf(pos, weak)
+		mult := strong.Multiplier()
+
+		switch {
+		case sm == kpMaterial && wm == kMaterial:
+			if probeKPK(pos, strong) {
+				e.bonus = mult * kpkWinBonus
+			} else {
+				e.scale = scaleDraw
+			}
+			return
+
+		case sm == kbnMaterial && wm == kMaterial:
+			e.scale, e.bonus = scaleDraw, mult*evaluateKBNK(pos, strong)
+			return
+
+		case sm == krMaterial && wm == kpMaterial:
+			if isDrawishKRKP(pos, strong) {
+				e.scale = scaleHard
+			}
+			return
+
+		case sm == kqMaterial && wm == kpMaterial:
+			if isDrawishKQKP(pos, strong) {
+				e.scale = scaleHard
+			}
+			return
+
+		case sm.pieces() == kbMaterial && wm == kMaterial:
+			if isWrongBishop(pos, strong) {
+				e.scale = scaleDraw
+			}
+			return
+		}
+	}
+
+	if isOppositeBishops(pos) {
+		e.scale = scaleOppositeBishops
+	}
+}
+
+// isKnownDraw returns true if pos is a draw with best play.
+// Only exact knowledge is used, i.e. the KPK bitbase.
+func isKnownDraw(pos *Position) bool {
+	all := pos.ByColor(White) | pos.ByColor(Black)
+	if all.Count() != 3 || pos.ByFigure(Pawn).Count() != 1 {
+		return false
+	}
+	strong := White
+	if pos.ByPiece(Black, Pawn) != 0 {
+		strong = Black
+	}
+	return !probeKPK(pos, strong)
+}
+
+// material counts the pieces of one side.
+// The counts are packed four bits per figure.
+type material uint32
+
+// pawnsShift is the position of the pawns count in a material.
+const pawnsShift = 4 * uint(King)
+
+// Material of the recognized endgames.
+var (
+	kMaterial   = material(0)
+	kpMaterial  = material(1 << pawnsShift)
+	kbMaterial  = material(1 << (4 * Bishop))
+	kbnMaterial = material(1<<(4*Bishop) | 1<<(4*Knight))
+	krMaterial  = material(1 << (4 * Rook))
+	kqMaterial  = material(1 << (4 * Queen))
+)
+
+// materialOf returns the material of side us.
+func materialOf(pos *Position, us Color) material {
+	m := material(min(pos.ByPiece(us, Pawn).Count(), 15)) << pawnsShift
+	for fig := Knight; fig <= Queen; fig++ {
+		m |= material(min(pos.ByPiece(us, fig).Count(), 15)) << (4 * uint(fig))
+	}
+	return m
+}
+
+// count returns the number of pieces of figure fig in m.
+func (m material) count(fig Figure) int32 {
+	if fig == Pawn {
+		return int32(m>>pawnsShift) & 15
+	}
+	return int32(m>>(4*uint(fig))) & 15
+}
+
+// pieces returns the material without the pawns.
+func (m material) pieces() material {
+	return m & (1<<pawnsShift - 1)
+}
+
+// evaluateKBNK returns the score of the side with the bishop and the knight.
+// The weak king must be driven to a corner of the colour of the bishop.
+func evaluateKBNK(pos *Position, strong Color) int32 {
+	weakKing := Kings(pos, strong.Opposite()).AsSquare()
+	corners := [2]Square{SquareA1, SquareH8}
+	if pos.ByPiece(strong, Bishop)&BbWhiteSquares != 0 {
+		corners = [2]Square{SquareA8, SquareH1}
+	}
+	corner := min(distance[weakKing][corners[0]], distance[weakKing][corners[1]])
+	kings := distance[weakKing][Kings(pos, strong).AsSquare()]
+	return kbnkWinScore + 20*(7-corner) + 5*(7-kings)
+}
+
+// isDrawishKRKP returns true if the pawn is far advanced, supported by its
+// king and the strong king is too far to help the rook stop it.
+func isDrawishKRKP(pos *Position, strong Color) bool {
+	weak := strong.Opposite()
+	pawn := pos.ByPiece(weak, Pawn).AsSquare()
+	if pawn.POV(weak).Rank() < 5 {
+		return false
+	}
+	if ForwardSpan(weak, pawn.Bitboard()).Has(Kings(pos, strong).AsSquare()) {
+		return false // the strong king blocks the pawn
+	}
+	return distance[Kings(pos, weak).AsSquare()][pawn] <= 1 &&
+		distance[Kings(pos, strong).AsSquare()][pawn] >= 3
+}
+
+// isDrawishKQKP returns true if the pawn on a rook or bishop file is about
+// to promote, supported by its king and the strong king is too far.
+// The defence is based on stalemate.
+func isDrawishKQKP(pos *Position, strong Color) bool {
+	weak := strong.Opposite()
+	pawn := pos.ByPiece(weak, Pawn).AsSquare()
+	if pawn.POV(weak).Rank() != 6 {
+		return false
+	}
+	if f := pawn.File(); f != 0 && f != 2 && f != 5 && f != 7 {
+		return false
+	}
+	return distance[Kings(pos, weak).AsSquare()][pawn] <= 1 &&
+		distance[Kings(pos, strong).AsSquare()][pawn] >= 3
+}
+
+// isWrongBishop returns true if the strong side has only rook pawns on one
+// file, the bishop does not control the promotion square and the weak
+// king is next to the promotion square.
+func isWrongBishop(pos *Position, strong Color) bool {
+	pawns := pos.ByPiece(strong, Pawn)
+	var file int
+	switch {
+	case pawns == 0:
+		return false
+	case pawns&^BbFileA == 0:
+		file = 0
+	case pawns&^BbFileH == 0:
+		file = 7
+	default:
+		return false
+	}
+
+	promotion := RankFile(7, file).POV(strong)
+	lightBishop := pos.ByPiece(strong, Bishop)&BbWhiteSquares != 0
+	if lightBishop == BbWhiteSquares.Has(promotion) {
+		return false // right bishop
+	}
+	return distance[Kings(pos, strong.Opposite()).AsSquare()][promotion] <= 1
+}
+
+// isOppositeBishops returns true if each side has only one bishop
+// and pawns and the bishops are on squares of different colours.
+func isOppositeBishops(pos *Position) bool {
+	if materialOf(pos, White).pieces() != kbMaterial || materialOf(pos, Black).pieces() != kbMaterial {
+		return false
+	}
+	bishops := pos.ByFigure(Bishop)
+	return bishops&BbWhiteSquares != 0 && bishops&BbBlackSquares != 0
+}
+
+// KPK bitbase.
+//
+// Positions are seen from the side with the pawn, White, with the pawn
+// on files a to d. Each position is indexed by the side to move, the
+// squares of the kings and the square of the pawn.
+const (
+	kpkPawnSquares = 24 // files a-d, ranks 2-7
+	kpkSize        = 2 * SquareArraySize * SquareArraySize * kpkPawnSquares
+)
+
+// Results of KPK positions used during the generation.
+const (
+	kpkUnknown uint8 = iota
+	kpkInvalid
+	kpkDraw
+	kpkWin
+)
+
+var (
+	kpkOnce   sync.Once
+	kpkBitset [kpkSize / 64]uint64 // bit is set if the side with the pawn wins
+)
+
+// kpkIndex returns the index of the position. stm is 0 for White, 1 for Black.
+func kpkIndex(stm int, wk, bk, psq Square) int {
+	p := (psq.Rank()-1)*4 + psq.File()
+	return ((stm*SquareArraySize+int(wk))*SquareArraySize+int(bk))*kpkPawnSquares + p
+}
+
+// probeKPK returns true if strong, the side with the pawn, wins KPK.
+func probeKPK(pos *Position, strong Color) bool {
+	kpkOnce.Do(initKPK)
+
+	wk := Kings(pos, strong).AsSquare().POV(strong)
+	bk := Kings(pos, strong.Opposite()).AsSquare().POV(strong)
+	psq := pos.ByPiece(strong, Pawn).AsSquare().POV(strong)
+	if psq.File() >= 4 {
+		wk = RankFile(wk.Rank(), 7-wk.File())
+		bk = RankFile(bk.Rank(), 7-bk.File())
+		psq = RankFile(psq.Rank(), 7-psq.File())
+	}
+	stm := 0
+	if pos.Us() != strong {
+		stm = 1
+	}
+	idx := kpkIndex(stm, wk, bk, psq)
+	return kpkBitset[idx/64]&(1<<uint(idx%64)) != 0
+}
+
+// initKPK generates the KPK bitbase by retrograde analysis.
+// Positions that are not won after the iterations end are draws.
+func initKPK() {
+	results := make([]uint8, kpkSize)
+	forEachKPK(func(stm int, wk, bk, psq Square) {
+		results[kpkIndex(stm, wk, bk, psq)] = kpkInitial(stm, wk, bk, psq)
+	})
+	for changed := true; changed; {
+		changed = false
+		forEachKPK(func(stm int, wk, bk, psq Square) {
+			idx := kpkIndex(stm, wk, bk, psq)
+			if results[idx] == kpkUnknown {
+				if results[idx] = kpkClassify(results, stm, wk, bk, psq); results[idx] != kpkUnknown {
+					changed = true
+				}
+			}
+		})
+	}
+	for idx, r := range results {
+		if r == kpkWin {
+			kpkBitset[idx/64] |= 1 << uint(idx%64)
+		}
+	}
+}
+
+// forEachKPK calls f for every KPK position.
+func forEachKPK(f func(stm int, wk, bk, psq Square)) {
+	for stm := 0; stm < 2; stm++ {
+		for wk := SquareMinValue; wk <= SquareMaxValue; wk++ {
+			for bk := SquareMinValue; bk <= SquareMaxValue; bk++ {
+				for r := 1; r < 7; r++ {
+					for file := 0; file < 4; file++ {
+						f(stm, wk, bk, RankFile(r, file))
+					}
+				}
+			}
+		}
+	}
+}
+
+// kpkPawnAttacks returns the squares attacked by the white pawn on psq.
+func kpkPawnAttacks(psq Square) Bitboard {
+	bb := North(psq.Bitboard())
+	return East(bb) | West(bb)
+}
+
+// kpkInitial classifies the positions that can be decided without search.
+func kpkInitial(stm int, wk, bk, psq Square) uint8 {
+	attacks := kpkPawnAttacks(psq)
+	if distance[wk][bk] <= 1 || wk == psq || bk == psq || stm == 0 && attacks.Has(bk) {
+		return kpkInvalid
+	}
+
+	promotion := psq + 8
+	if stm == 0 && psq.Rank() == 6 && wk != promotion && bk != promotion &&
+		(distance[bk][promotion] > 1 || distance[wk][promotion] == 1) {
+		return kpkWin // the pawn promotes safely
+	}
+	if stm == 1 {
+		if KingMobility(bk)&^(KingMobility(wk)|attacks) == 0 {
+			if attacks.Has(bk) {
+				return kpkWin // mate
+			}
+			return kpkDraw // stalemate
+		}
+		if distance[bk][psq] == 1 && distance[wk][psq] > 1 {
+			return kpkDraw // the pawn is captured
+		}
+	}
+	return kpkUnknown
+}
+
+// kpkClassify classifies the position from the results of its successors.
+func kpkClassify(results []uint8, stm int, wk, bk, psq Square) uint8 {
+	if stm == 0 {
+		// White wins if any move wins.
+		r := kpkDraw
+		for bb := KingMobility(wk); bb != 0; {
+			if to := bb.Pop(); to != psq {
+				r = kpkBest(r, results[kpkIndex(1, to, bk, psq)])
+			}
+		}
+		if psq.Rank() < 6 && psq+8 != wk && psq+8 != bk {
+			r = kpkBest(r, results[kpkIndex(1, wk, bk, psq+8)])
+			if psq.Rank() == 1 && psq+16 != wk && psq+16 != bk {
+				r = kpkBest(r, results[kpkIndex(1, wk, bk, psq+16)])
+			}
+		}
+		return r
+	}
+
+	// Black draws if any move draws.
+	r := kpkWin
+	for bb := KingMobility(bk); bb != 0; {
+		if to := bb.Pop(); to != psq {
+			switch results[kpkIndex(0, wk, to, psq)] {
+			case kpkDraw:
+				return kpkDraw
+			case kpkUnknown:
+				r = kpkUnknown
+			}
+		}
+	}
+	return r
+}
+
+// kpkBest combines the result of a White move into r.
+func kpkBest(r, move uint8) uint8 {
+	switch {
+	case r == kpkWin || move == kpkWin:
+		return kpkWin
+	case r == kpkUnknown || move == kpkUnknown:
+		return kpkUnknown
+	}
+	return r
+}
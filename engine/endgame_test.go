@@ -0,0 +1,114 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package engine
+
+import (
+	"testing"
+
+	. "bitbucket.org/zurichess/board"
+)
+
+func TestKPK(t *testing.T) {
+	data := []struct {
+		fen string
+		win bool
+	}{
+		// King in front of the pawn on the 6th rank wins.
+		{"4k3/8/4K3/4P3/8/8/8/8 w - - 0 1", true},
+		{"4k3/8/4K3/4P3/8/8/8/8 b - - 0 1", true},
+		// The pawn on the 6th rank with the king behind.
+		{"4k3/8/4P3/4K3/8/8/8/8 w - - 0 1", false},
+		{"4k3/8/4P3/4K3/8/8/8/8 b - - 0 1", false},
+		{"3k4/8/4P3/4K3/8/8/8/8 w - - 0 1", true},
+		// Rook pawn with the defending king in the corner.
+		{"k7/8/K7/P7/8/8/8/8 w - - 0 1", false},
+		{"7k/8/7K/7P/8/8/8/8 b - - 0 1", false},
+		// The defending king is outside the square of the pawn.
+		{"8/8/8/8/8/k7/7P/7K w - - 0 1", true},
+		{"8/8/8/8/8/k7/7P/7K b - - 0 1", true},
+		{"8/8/8/8/k7/8/7P/7K b - - 0 1", true},
+		{"8/8/8/8/8/8/k6P/7K b - - 0 1", true},
+		{"8/8/8/8/8/1k6/7P/7K b - - 0 1", false},
+		// Same positions for Black.
+		{"8/8/8/8/4p3/4k3/8/4K3 b - - 0 1", true},
+		{"8/8/8/8/4k3/4p3/8/4K3 b - - 0 1", false},
+		{"8/8/8/8/4k3/4p3/8/4K3 w - - 0 1", false},
+		{"7k/7p/K7/8/8/8/8/8 b - - 0 1", true},
+		{"8/k7/p7/K7/8/8/8/8 b - - 0 1", false},
+	}
+	for _, d := range data {
+		pos, err := PositionFromFEN(d.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		strong := White
+		if pos.ByPiece(Black, Pawn) != 0 {
+			strong = Black
+		}
+		if win := probeKPK(pos, strong); win != d.win {
+			t.Errorf("%s: got win %v, wanted %v", d.fen, win, d.win)
+		}
+		if draw := isKnownDraw(pos); draw == d.win {
+			t.Errorf("%s: got known draw %v, wanted %v", d.fen, draw, !d.win)
+		}
+
+		score := Evaluate(pos).GetCentipawnsScore() * strong.Multiplier()
+		if d.win && score < kpkWinBonus || !d.win && score != 0 {
+			t.Errorf("%s: got score %d", d.fen, score)
+		}
+	}
+}
+
+func TestEndgameScale(t *testing.T) {
+	data := []struct {
+		fen   string
+		scale int32
+	}{
+		// Wrong and right bishop.
+		{"7k/8/6KP/8/8/8/8/1B6 w - - 0 1", scaleDraw},
+		{"7k/8/6KP/8/8/8/8/B7 w - - 0 1", scaleNormal},
+		{"8/8/8/8/8/p7/2k5/K4b2 b - - 0 1", scaleDraw},
+		{"8/8/8/8/8/p7/2k5/K3b3 b - - 0 1", scaleNormal},
+		// Opposite coloured bishops.
+		{"8/5k2/2b2p2/1p6/1P3P2/2B5/5K2/8 w - - 0 1", scaleOppositeBishops},
+		{"8/5k2/3b1p2/1p6/1P3P2/2B5/5K2/8 w - - 0 1", scaleNormal},
+		{"8/5k2/2b2p2/1p6/1P3P2/2B5/5K2/3R4 w - - 0 1", scaleNormal},
+		// KRKP with an advanced pawn and the strong king far.
+		{"8/8/8/8/8/1pk5/8/7K w - - 0 1", scaleNormal},
+		{"R7/8/8/8/8/1pk5/8/7K w - - 0 1", scaleHard},
+		{"R7/8/8/8/1pk5/8/8/7K w - - 0 1", scaleNormal},
+		{"R7/8/8/8/8/2k5/1p6/7K w - - 0 1", scaleHard},
+		{"R7/8/8/8/8/2k5/1p6/1K6 w - - 0 1", scaleNormal},
+		// KQKP with a bishop pawn on the 7th rank.
+		{"Q7/8/8/8/8/8/2pk4/7K w - - 0 1", scaleHard},
+		{"Q7/8/8/8/8/8/3pk3/7K w - - 0 1", scaleNormal},
+		{"Q7/8/8/8/8/8/2pk4/2K5 w - - 0 1", scaleNormal},
+	}
+	for _, d := range data {
+		pos, err := PositionFromFEN(d.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e := Evaluate(pos); e.scale != d.scale {
+			t.Errorf("%s: got scale %d, wanted %d", d.fen, e.scale, d.scale)
+		}
+	}
+}
+
+func TestKBNK(t *testing.T) {
+	// With a dark squared bishop the king must be driven to a1 or h8.
+	var scores []int32
+	for _, fen := range []string{
+		"8/8/8/3NB3/8/8/8/k4K2 b - - 0 1",  // right corner
+		"8/8/8/3NB3/4k3/8/8/5K2 b - - 0 1", // center
+		"k7/8/8/3NB3/8/8/8/5K2 b - - 0 1",  // wrong corner
+	} {
+		pos, _ := PositionFromFEN(fen)
+		scores = append(scores, Evaluate(pos).GetCentipawnsScore())
+	}
+	if !(scores[0] > scores[1] && scores[1] > scores[2] && scores[2] >= kbnkWinScore) {
+		t.Errorf("got scores %v for the king in the right corner, center and wrong corner", scores)
+	}
+}
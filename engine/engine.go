@@ -53,6 +53,7 @@ package engine
 
 import (
 	"math/rand"
+	"sync"
 
 	. "bitbucket.org/zurichess/board"
 )
@@ -68,7 +69,7 @@ const (
 )
 
 var (
-	initialized = false
+	initOnce sync.Once // initializes the package once
 )
 
 // Options keeps engine's options.
@@ -76,6 +77,18 @@ type Options struct {
 	AnalyseMode   bool // true to display info strings
 	MultiPV       int  // number of principal variation lines to compute
 	HandicapLevel int
+	// Contempt is the score of a draw, in centipawns, for the opponent of
+	// the side to move at root. Positive values avoid draws. Ignored in
+	// AnalyseMode so that the scores of both sides are comparable.
+	Contempt int32
+	// Deterministic resets all tables before each search and seeds the
+	// random number generator with Seed, so the same inputs give the
+	// same search. The time control should be deterministic, too.
+	Deterministic bool
+	Seed          int64
+	// AllMoves reports a score for every root move after each depth
+	// to loggers implementing RootMovesLogger.
+	AllMoves bool
 }
 
 // Stats stores statistics about the search.
@@ -85,6 +98,22 @@ type Stats struct {
 	Nodes     uint64 // number of nodes searched
 	Depth     int32  // depth search
 	SelDepth  int32  // maximum depth reached on PV (doesn't include the hash moves)
+
+	QNodes           uint64 // number of quiescence nodes searched, included in Nodes
+	CutOffs          uint64 // number of beta cut-offs in the main search
+	FirstMoveCutOffs uint64 // number of beta cut-offs produced by the first move searched
+	NullMoveTries    uint64 // number of null moves searched
+	NullMoveCutOffs  uint64 // number of null moves that failed high
+	Razorings        uint64 // number of nodes pruned by razoring
+	FutilityPrunes   uint64 // number of moves pruned by futility and history leaf pruning
+	LMRReductions    uint64 // number of moves searched with reduced depth
+	LMRResearches    uint64 // number of reduced moves searched again with full depth
+	HashExactCutOffs uint64 // number of transposition table cut-offs on exact scores
+	HashLowerCutOffs uint64 // number of transposition table cut-offs on lower bounds
+	HashUpperCutOffs uint64 // number of transposition table cut-offs on upper bounds
+	Evaluations      uint64 // number of full static evaluations
+	EvalCacheHits    uint64 // number of static evaluations found in the evaluation cache
+	LazyEvaluations  uint64 // number of static evaluations estimated by lazy evaluation
 }
 
 // CacheHitRatio returns the ratio of transposition table hits over total number of lookups.
@@ -92,6 +121,33 @@ func (s *Stats) CacheHitRatio() float32 {
 	return float32(s.CacheHit) / float32(s.CacheHit+s.CacheMiss)
 }
 
+// FirstMoveCutOffRatio returns the ratio of beta cut-offs produced by the first move.
+// A high ratio indicates good move ordering.
+func (s *Stats) FirstMoveCutOffRatio() float32 {
+	return float32(s.FirstMoveCutOffs) / float32(s.CutOffs)
+}
+
+// NullMoveCutOffRatio returns the ratio of null moves that failed high.
+func (s *Stats) NullMoveCutOffRatio() float32 {
+	return float32(s.NullMoveCutOffs) / float32(s.NullMoveTries)
+}
+
+// LMRResearchRatio returns the ratio of reduced moves that had to be searched again.
+func (s *Stats) LMRResearchRatio() float32 {
+	return float32(s.LMRResearches) / float32(s.LMRReductions)
+}
+
+// hashCutOff updates the transposition table cut-offs for an entry of kind.
+func (s *Stats) hashCutOff(kind hashFlags) {
+	if kind&exact != 0 {
+		s.HashExactCutOffs++
+	} else if kind&failedHigh != 0 {
+		s.HashLowerCutOffs++
+	} else {
+		s.HashUpperCutOffs++
+	}
+}
+
 // Logger logs search progress.
 type Logger interface {
 	// BeginSearch signals a new search is started.
@@ -163,17 +219,29 @@ func (ht *historyTable) add(m Move, delta int32) {
 
 // Engine implements the logic to search for the best move for a position.
 type Engine struct {
-	Options  Options   // engine options
-	Log      Logger    // logger
-	Stats    Stats     // search statistics
-	Position *Position // current Position
-
-	rootPly         int           // position's ply at the start of the search
-	stack           stack         // stack of moves
-	pvTable         pvTable       // principal variation table
-	history         *historyTable // keeps history of moves
-	ignoreRootMoves []Move        // moves to ignore at root
-	onlyRootMoves   []Move        // search only these root moves
+	Options   Options    // engine options
+	Log       Logger     // logger
+	Stats     Stats      // search statistics
+	Position  *Position  // current Position
+	Tracer    *Tracer    // search tree tracer, nil to disable tracing
+	HashTable *HashTable // transposition table, nil to use GlobalHashTable
+	Network   *Network   // evaluation network, nil to use the classic evaluation
+
+	rootPly         int                // position's ply at the start of the search
+	rootColor       Color              // side to move at the start of the search
+	pawns           *pawnsTable        // cache for pawns and shelter evaluation
+	materials       *materialTable     // cache for the material evaluation
+	evals           *evalTable         // cache for the static evaluation
+	psqt            psqtState          // material and piece square scores of the positions on the stack
+	nnue            nnueState          // accumulators of Network
+	stack           stack              // stack of moves
+	pvTable         pvTable            // principal variation table
+	history         *historyTable      // keeps history of moves
+	ignoreRootMoves []Move             // moves to ignore at root
+	onlyRootMoves   []Move             // search only these root moves
+	rootNodes       map[Move]uint64    // nodes searched for each root move at current depth
+	rootScores      map[Move]rootScore // scores of the root moves at current depth
+	rand            *rand.Rand         // random number generator for the deterministic mode
 
 	timeControl *TimeControl
 	stopped     bool   // true if timeControl stopped the clock
@@ -190,13 +258,19 @@ func NewEngine(pos *Position, log Logger, options Options) *Engine {
 	if log == nil {
 		log = &NulLogger{}
 	}
+	initOnce.Do(initEngine)
 	history := &historyTable{}
 	eng := &Engine{
-		Options: options,
-		Log:     log,
-		pvTable: newPvTable(),
-		history: history,
-		stack:   stack{history: history},
+		Options:    options,
+		Log:        log,
+		pvTable:    newPvTable(),
+		history:    history,
+		stack:      stack{history: history},
+		rootNodes:  make(map[Move]uint64),
+		rootScores: make(map[Move]rootScore),
+		pawns:      &pawnsTable{},
+		materials:  &materialTable{},
+		evals:      &evalTable{},
 	}
 	eng.SetPosition(pos)
 	return eng
@@ -210,22 +284,63 @@ func (eng *Engine) SetPosition(pos *Position) {
 	} else {
 		eng.Position, _ = PositionFromFEN(FENStartPos)
 	}
+	eng.rootColor = eng.Position.Us()
+	eng.psqt.reset()
+	eng.nnue.reset(eng.Network)
+}
+
+// hashTable returns the transposition table used by eng.
+func (eng *Engine) hashTable() *HashTable {
+	if eng.HashTable != nil {
+		return eng.HashTable
+	}
+	return GlobalHashTable
+}
+
+// nnueState returns the accumulators of eng.Network.
+func (eng *Engine) nnueState() *nnueState {
+	if eng.nnue.net != eng.Network {
+		eng.nnue.reset(eng.Network)
+	}
+	return &eng.nnue
 }
 
 // DoMove executes a move.
 func (eng *Engine) DoMove(move Move) {
+	prev := eng.Position.Zobrist()
 	eng.Position.DoMove(move)
-	GlobalHashTable.prefetch(eng.Position)
+	eng.hashTable().prefetch(eng.Position)
+	eng.psqt.doMove(prev, move, eng.Position)
+	if eng.Network != nil {
+		eng.nnueState().doMove(prev, move, eng.Position)
+	}
 }
 
 // UndoMove undoes the last move.
 func (eng *Engine) UndoMove() {
 	eng.Position.UndoMove()
+	eng.psqt.pop()
+	if eng.Network != nil {
+		eng.nnueState().pop()
+	}
 }
 
 // Score evaluates current position from current player's POV.
+// Unlike Evaluate, Score can be used concurrently by different engines.
 func (eng *Engine) Score() int32 {
-	return Evaluate(eng.Position).GetCentipawnsScore() * eng.Position.Us().Multiplier()
+	pos := eng.Position
+	if eng.Network != nil {
+		ns := eng.nnueState()
+		return ns.evaluate(ns.current(pos), pos.Us())
+	}
+	if score, ok := eng.evals.get(pos.Zobrist()); ok {
+		eng.Stats.EvalCacheHits++
+		return score * pos.Us().Multiplier()
+	}
+	eng.Stats.Evaluations++
+	score := evaluatePosition(pos, eng.pawns, eng.materials).GetCentipawnsScore()
+	eng.evals.put(pos.Zobrist(), score)
+	return score * pos.Us().Multiplier()
 }
 
 // cachedScore implements a cache on top of Score.
@@ -238,40 +353,74 @@ func (eng *Engine) cachedScore(e *hashEntry) int32 {
 	return int32(e.static)
 }
 
+// lazyScore is like cachedScore, but if the static score is not known
+// and the estimate of the material and piece square scores is at least
+// lazyMargin outside (α, β) the estimate is returned instead.
+// exact is false if the score is an estimate.
+func (eng *Engine) lazyScore(e *hashEntry, α, β int32) (score int32, exact bool) {
+	pos := eng.Position
+	if e.kind&hasStatic == 0 && eng.Network == nil && !mayBeKnownEndgame(pos) {
+		if _, ok := eng.evals.get(pos.Zobrist()); !ok {
+			score := eng.psqt.estimate(pos, eng.pawns, eng.materials) * pos.Us().Multiplier()
+			if score-lazyMargin >= β || score+lazyMargin <= α {
+				eng.Stats.LazyEvaluations++
+				return score, false
+			}
+		}
+	}
+	return eng.cachedScore(e), true
+}
+
+// drawScore returns the score of a draw from the side to move POV.
+func (eng *Engine) drawScore() int32 {
+	if eng.Options.AnalyseMode {
+		return 0
+	}
+	if eng.Position.Us() == eng.rootColor {
+		return -eng.Options.Contempt
+	}
+	return eng.Options.Contempt
+}
+
 // endPosition determines whether the current position is an end game.
-// Returns score and a bool if the game has ended.
-func (eng *Engine) endPosition() (int32, bool) {
+// Returns score, a bool if the game has ended and a bool if it's a draw.
+func (eng *Engine) endPosition() (int32, bool, bool) {
 	pos := eng.Position // shortcut
+	draw := eng.drawScore()
 	// Trivial cases when kings are missing.
 	if Kings(pos, White) == 0 {
 		if Kings(pos, Black) == 0 {
-			return 0, true // both kings are missing
+			return draw, true, true // both kings are missing
 		}
-		return pos.Us().Multiplier() * (MatedScore + eng.ply()), true
+		return pos.Us().Multiplier() * (MatedScore + eng.ply()), true, false
 	}
 	if Kings(pos, Black) == 0 {
-		return pos.Us().Multiplier() * (MateScore - eng.ply()), true
+		return pos.Us().Multiplier() * (MateScore - eng.ply()), true, false
 	}
 	// Neither side cannot mate.
 	if pos.InsufficientMaterial() {
-		return 0, true
+		return draw, true, true
+	}
+	// Known draws, e.g. KPK.
+	if isKnownDraw(pos) {
+		return draw, true, true
 	}
 	// Fifty full moves without a capture or a pawn move.
 	if pos.FiftyMoveRule() {
-		return 0, true
+		return draw, true, true
 	}
 	// Repetition is a draw.
 	// At root we need to continue searching even if we saw two repetitions already,
 	// however we can prune deeper search only at two repetitions.
 	if r := pos.ThreeFoldRepetition(); eng.ply() > 0 && r >= 2 || r >= 3 {
-		return 0, true
+		return draw, true, true
 	}
-	return 0, false
+	return 0, false, false
 }
 
-// retrieveHash gets from GlobalHashTable the current position.
+// retrieveHash gets the current position from the transposition table.
 func (eng *Engine) retrieveHash() hashEntry {
-	entry := GlobalHashTable.get(eng.Position)
+	entry := eng.hashTable().get(eng.Position)
 	if entry.kind == 0 || entry.move != NullMove && !eng.Position.IsPseudoLegal(entry.move) {
 		eng.Stats.CacheMiss++
 		return hashEntry{}
@@ -286,10 +435,13 @@ func (eng *Engine) retrieveHash() hashEntry {
 	}
 
 	eng.Stats.CacheHit++
+	if eng.Tracer != nil {
+		eng.Tracer.hashHit()
+	}
 	return entry
 }
 
-// updateHash updates GlobalHashTable with the current position.
+// updateHash updates the transposition table with the current position.
 func (eng *Engine) updateHash(flags hashFlags, depth, score int32, move Move, static int32) {
 	// If search is stopped then score cannot be trusted.
 	if eng.stopped {
@@ -313,7 +465,7 @@ func (eng *Engine) updateHash(flags hashFlags, depth, score int32, move Move, st
 		score += eng.ply()
 	}
 
-	GlobalHashTable.put(eng.Position, hashEntry{
+	eng.hashTable().put(eng.Position, hashEntry{
 		kind:   flags,
 		score:  int16(score),
 		depth:  int8(depth),
@@ -323,22 +475,43 @@ func (eng *Engine) updateHash(flags hashFlags, depth, score int32, move Move, st
 }
 
 // searchQuiescence evaluates the position after solving all captures.
+// See doSearchQuiescence.
+func (eng *Engine) searchQuiescence(α, β int32) int32 {
+	if eng.Tracer == nil {
+		return eng.doSearchQuiescence(α, β)
+	}
+	eng.Tracer.enter(eng.Position, eng.ply(), α, β, 0, true)
+	score := eng.doSearchQuiescence(α, β)
+	eng.Tracer.leave(score)
+	return score
+}
+
+// doSearchQuiescence evaluates the position after solving all captures.
 //
 // This is a very limited search which considers only some violent moves.
 // Depth is ignored, so hash uses depth 0. Search continues until
 // stand pat or no capture can improve the score.
-func (eng *Engine) searchQuiescence(α, β int32) int32 {
+func (eng *Engine) doSearchQuiescence(α, β int32) int32 {
 	eng.Stats.Nodes++
+	eng.Stats.QNodes++
 
 	entry := eng.retrieveHash()
 	if score := int32(entry.score); isInBounds(entry.kind, α, β, score) {
+		eng.Stats.hashCutOff(entry.kind)
+		eng.trace(traceHash)
 		return score
 	}
 
-	static := eng.cachedScore(&entry)
+	// The static score is stored in the hash only if it is exact.
+	static, exact := eng.lazyScore(&entry, α, β)
+	staticFlag := hashFlags(0)
+	if exact {
+		staticFlag = hasStatic
+	}
 	if static >= β {
 		// Stand pat if the static score is already a cut-off.
-		eng.updateHash(failedHigh|hasStatic, 0, static, entry.move, static)
+		eng.updateHash(failedHigh|staticFlag, 0, static, entry.move, static)
+		eng.trace(traceStandPat)
 		return static
 	}
 
@@ -366,7 +539,8 @@ func (eng *Engine) searchQuiescence(α, β int32) int32 {
 		eng.UndoMove()
 
 		if score >= β {
-			eng.updateHash(failedHigh|hasStatic, 0, score, move, static)
+			eng.updateHash(failedHigh|staticFlag, 0, score, move, static)
+			eng.trace(traceCutOff)
 			return score
 		}
 		if score > localα {
@@ -375,7 +549,7 @@ func (eng *Engine) searchQuiescence(α, β int32) int32 {
 		}
 	}
 
-	eng.updateHash(getBound(α, β, localα)|hasStatic, 0, localα, bestMove, static)
+	eng.updateHash(getBound(α, β, localα)|staticFlag, 0, localα, bestMove, static)
 	return localα
 }
 
@@ -394,7 +568,13 @@ func (eng *Engine) tryMove(α, β, depth, lmr int32, nullWindow bool) int32 {
 
 	score := α + 1
 	if lmr > 0 { // reduce late moves
+		eng.Stats.LMRReductions++
+		eng.trace(traceLMR)
 		score = -eng.searchTree(-α-1, -α, depth-lmr)
+		if score > α {
+			eng.Stats.LMRResearches++
+			eng.trace(traceLMRResearch)
+		}
 	}
 
 	if score > α { // if late move reduction is disabled or has failed
@@ -412,6 +592,19 @@ func (eng *Engine) tryMove(α, β, depth, lmr int32, nullWindow bool) int32 {
 	return score
 }
 
+// rootNodesShare returns the fraction of root nodes spent
+// searching move at the current depth.
+func (eng *Engine) rootNodesShare(move Move) float64 {
+	total := uint64(0)
+	for _, n := range eng.rootNodes {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(eng.rootNodes[move]) / float64(total)
+}
+
 // ply returns the ply from the beginning of the search.
 func (eng *Engine) ply() int32 {
 	return int32(eng.Position.Ply - eng.rootPly)
@@ -436,6 +629,18 @@ func (eng *Engine) isIgnoredRootMove(move Move) bool {
 }
 
 // searchTree implements searchTree framework.
+// See doSearchTree.
+func (eng *Engine) searchTree(α, β, depth int32) int32 {
+	if eng.Tracer == nil {
+		return eng.doSearchTree(α, β, depth)
+	}
+	eng.Tracer.enter(eng.Position, eng.ply(), α, β, depth, false)
+	score := eng.doSearchTree(α, β, depth)
+	eng.Tracer.leave(score)
+	return score
+}
+
+// doSearchTree implements searchTree framework.
 //
 // searchTree fails soft, i.e. the score returned can be outside the bounds.
 //
@@ -452,7 +657,7 @@ func (eng *Engine) isIgnoredRootMove(move Move) bool {
 //
 // Assuming this is a maximizing nodes, failing high means that a
 // minimizing ancestor node already has a better alternative.
-func (eng *Engine) searchTree(α, β, depth int32) int32 {
+func (eng *Engine) doSearchTree(α, β, depth int32) int32 {
 	ply := eng.ply()
 	pvNode := α+1 < β
 	pos := eng.Position
@@ -461,12 +666,13 @@ func (eng *Engine) searchTree(α, β, depth int32) int32 {
 	// Update statistics.
 	eng.Stats.Nodes++
 	if !eng.stopped && eng.Stats.Nodes >= eng.checkpoint {
-		eng.checkpoint = eng.Stats.Nodes + checkpointStep
-		if eng.timeControl.Stopped() {
+		eng.checkpoint = eng.nextCheckpoint()
+		if eng.timeControl.Stopped() || eng.timeControl.nodesExceeded(eng.Stats.Nodes) {
 			eng.stopped = true
 		}
 	}
 	if eng.stopped {
+		eng.trace(traceStopped)
 		return α
 	}
 	if pvNode && ply > eng.Stats.SelDepth {
@@ -474,17 +680,19 @@ func (eng *Engine) searchTree(α, β, depth int32) int32 {
 	}
 
 	// Verify that this is not already an endgame.
-	if score, done := eng.endPosition(); done && (ply != 0 || score != 0) {
+	if score, done, draw := eng.endPosition(); done && (ply != 0 || !draw) {
 		// At root we ignore draws because some GUIs don't properly detect
 		// theoretical draws. E.g. cutechess doesn't detect that kings and
 		// bishops when all bishops are on the same color. If the position
 		// is a theoretical draw, keep searching for a move.
+		eng.trace(traceEndPosition)
 		return score
 	}
 
 	// Mate pruning: If an ancestor already has a mate in ply moves then
 	// the search will always fail low so we return the lowest wining score.
 	if MateScore-ply <= α {
+		eng.trace(traceMatePruning)
 		return KnownWinScore
 	}
 
@@ -514,6 +722,8 @@ func (eng *Engine) searchTree(α, β, depth int32) int32 {
 			// If this is a CUT node, update the killer like in the regular move loop.
 			eng.stack.SaveKiller(hash)
 		}
+		eng.Stats.hashCutOff(entry.kind)
+		eng.trace(traceHash)
 		return score
 	}
 
@@ -527,10 +737,14 @@ func (eng *Engine) searchTree(α, β, depth int32) int32 {
 		MinorsAndMajors(pos, us) != 0 && // at least one minor/major piece.
 		KnownLossScore < α && β < KnownWinScore && // disable in lost or won positions
 		(entry.kind&hasStatic == 0 || int32(entry.static) >= β) {
+		eng.Stats.NullMoveTries++
 		eng.DoMove(NullMove)
 		reduction := 1 + depth/3
+		eng.trace(traceNullMove)
 		score := eng.tryMove(β-1, β, depth-reduction, 0, false)
 		if score >= β && score < KnownWinScore {
+			eng.Stats.NullMoveCutOffs++
+			eng.trace(traceNullMove)
 			return score
 		}
 	}
@@ -542,7 +756,10 @@ func (eng *Engine) searchTree(α, β, depth int32) int32 {
 		!pvNode && // disable in pv nodes
 		KnownLossScore < α && β < KnownWinScore { // disable when searching for a mate
 		rα := α - futilityMargin
+		eng.trace(traceRazoring)
 		if score := eng.searchQuiescence(rα, rα+1); score <= rα {
+			eng.Stats.Razorings++
+			eng.trace(traceRazoring)
 			return score
 		}
 	}
@@ -589,6 +806,8 @@ func (eng *Engine) searchTree(α, β, depth int32) int32 {
 			if isFutile(pos, static, α, depth*futilityMargin, move) ||
 				history < -10 && move.IsQuiet() ||
 				see(pos, move) < -futilityMargin {
+				eng.Stats.FutilityPrunes++
+				eng.tracePruned(move, max(α, localα), β, depth, traceFutility)
 				dropped = true
 				continue
 			}
@@ -627,13 +846,28 @@ func (eng *Engine) searchTree(α, β, depth int32) int32 {
 			continue
 		}
 
+		if newDepth > depth {
+			eng.trace(traceExtension)
+		}
+		nodes := eng.Stats.Nodes
 		score := eng.tryMove(max(α, localα), β, newDepth, lmr, numMoves > 1)
+		if ply == 0 {
+			eng.rootNodes[move] += eng.Stats.Nodes - nodes
+			if !eng.stopped {
+				eng.rootScores[move] = rootScore{score, getBound(max(α, localα), β, score)}
+			}
+		}
 
 		if score >= β {
 			// Fail high, cut node.
+			eng.Stats.CutOffs++
+			if numMoves == 1 {
+				eng.Stats.FirstMoveCutOffs++
+			}
 			eng.history.add(move, 5+5*depth)
 			eng.stack.SaveKiller(move)
 			eng.updateHash(failedHigh|(entry.kind&hasStatic), depth, score, move, int32(entry.static))
+			eng.trace(traceCutOff)
 			return score
 		}
 		if score > localα {
@@ -649,7 +883,7 @@ func (eng *Engine) searchTree(α, β, depth int32) int32 {
 		if sideIsChecked {
 			localα = MatedScore + ply
 		} else {
-			localα = 0
+			localα = eng.drawScore()
 		}
 	}
 
@@ -755,13 +989,50 @@ func (eng *Engine) searchMultiPV(depth, estimated int32) (int32, []Move) {
 	// and if the score is not too far off, return it.
 	s := int32(eng.Options.HandicapLevel)
 	d := s*s/2 + s*10 + 5
-	n := rand.Intn(len(pvs))
+	n := eng.randIntn(len(pvs))
 	for pvs[n].score+d < pvs[0].score {
 		n--
 	}
 	return pvs[n].score, pvs[n].moves
 }
 
+// randIntn returns a random number in [0, n).
+func (eng *Engine) randIntn(n int) int {
+	if eng.Options.Deterministic {
+		return eng.rand.Intn(n)
+	}
+	return rand.Intn(n)
+}
+
+// clearTables resets all tables and the random number
+// generator for a deterministic search.
+func (eng *Engine) clearTables() {
+	eng.hashTable().Clear()
+	for i := range eng.pvTable {
+		eng.pvTable[i] = pvEntry{}
+	}
+	*eng.history = historyTable{}
+	eng.stack.clearCounters()
+	*eng.pawns = pawnsTable{}
+	*eng.materials = materialTable{}
+	*eng.evals = evalTable{}
+	eng.rand = rand.New(rand.NewSource(eng.Options.Seed))
+}
+
+// nextCheckpoint returns the number of nodes searched
+// when the time and the node limits are checked next.
+func (eng *Engine) nextCheckpoint() uint64 {
+	nodes, limit := eng.Stats.Nodes, eng.timeControl.Nodes
+	if limit != 0 && nodes >= limit {
+		// The limit is ignored at low depths so check every node.
+		return nodes + 1
+	}
+	if limit != 0 && limit < nodes+checkpointStep {
+		return limit
+	}
+	return nodes + checkpointStep
+}
+
 // Play evaluates current position. See PlayMoves for the returned values.
 func (eng *Engine) Play(tc *TimeControl) (score int32, moves []Move) {
 	return eng.PlayMoves(tc, nil)
@@ -780,17 +1051,22 @@ func (eng *Engine) Play(tc *TimeControl) (score int32, moves []Move) {
 //
 // Time control, tc, should already be started.
 func (eng *Engine) PlayMoves(tc *TimeControl, rootMoves []Move) (score int32, moves []Move) {
-	if !initialized {
-		initEngine()
-	}
+	initOnce.Do(initEngine)
 
 	eng.Log.BeginSearch()
 	eng.Stats = Stats{Depth: -1}
+	if eng.Tracer != nil {
+		eng.Tracer.beginSearch()
+	}
 
 	eng.rootPly = eng.Position.Ply
+	eng.rootColor = eng.Position.Us()
 	eng.timeControl = tc
 	eng.stopped = false
-	eng.checkpoint = checkpointStep
+	eng.checkpoint = eng.nextCheckpoint()
+	if eng.Options.Deterministic {
+		eng.clearTables()
+	}
 	eng.stack.Reset(eng.Position)
 	eng.history.newSearch()
 	eng.onlyRootMoves = rootMoves
@@ -803,12 +1079,29 @@ func (eng *Engine) PlayMoves(tc *TimeControl, rootMoves []Move) (score int32, mo
 		}
 
 		eng.Stats.Depth = depth
-		if s, m := eng.searchMultiPV(depth, score); len(moves) == 0 || len(m) != 0 {
+		for m := range eng.rootNodes {
+			delete(eng.rootNodes, m)
+		}
+		for m := range eng.rootScores {
+			delete(eng.rootScores, m)
+		}
+		s, m := eng.searchMultiPV(depth, score)
+		if l, ok := eng.Log.(RootMovesLogger); ok && eng.Options.AllMoves && depth > 0 && len(m) != 0 {
+			l.PrintRootMoves(eng.Stats, eng.searchRootMoves(depth, s))
+		}
+		if len(moves) == 0 || len(m) != 0 {
+			if len(moves) != 0 && len(m) != 0 {
+				// Let the time control know how stable the search is.
+				tc.Feedback(moves[0] != m[0], score-s, eng.rootNodesShare(m[0]))
+			}
 			score, moves = s, m
 		}
 	}
 
 	eng.Log.EndSearch()
+	if eng.Tracer != nil {
+		eng.Tracer.endSearch()
+	}
 	if len(moves) == 0 && !eng.Position.HasLegalMoves() {
 		return 0, nil
 	} else if moves == nil {
@@ -845,6 +1138,5 @@ func initEngine() {
 			futilityFigureBonus[f] = Evaluate(pos).GetCentipawnsScore()
 		}
 	}
-
-	initialized = true
+	initPieceSquares()
 }
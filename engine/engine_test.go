@@ -5,6 +5,7 @@
 package engine
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 
@@ -174,3 +175,160 @@ func BenchmarkGame(b *testing.B) {
 		}
 	}
 }
+
+func TestStats(t *testing.T) {
+	if tuning {
+		t.Skip("the tuner has no weights")
+	}
+	for _, fen := range TestFENs[:8] {
+		pos, _ := PositionFromFEN(fen)
+		tc := NewFixedDepthTimeControl(pos, 5)
+		tc.Start(false)
+		eng := NewEngine(pos, nil, Options{})
+		eng.Play(tc)
+
+		s := eng.Stats
+		if s.QNodes > s.Nodes {
+			t.Errorf("%s: got %d qnodes > %d nodes", fen, s.QNodes, s.Nodes)
+		}
+		if s.FirstMoveCutOffs > s.CutOffs {
+			t.Errorf("%s: got %d first move cut-offs > %d cut-offs", fen, s.FirstMoveCutOffs, s.CutOffs)
+		}
+		if s.NullMoveCutOffs > s.NullMoveTries {
+			t.Errorf("%s: got %d null move cut-offs > %d tries", fen, s.NullMoveCutOffs, s.NullMoveTries)
+		}
+		if s.LMRResearches > s.LMRReductions {
+			t.Errorf("%s: got %d lmr researches > %d reductions", fen, s.LMRResearches, s.LMRReductions)
+		}
+		if hc := s.HashExactCutOffs + s.HashLowerCutOffs + s.HashUpperCutOffs; hc > s.CacheHit {
+			t.Errorf("%s: got %d hash cut-offs > %d hash hits", fen, hc, s.CacheHit)
+		}
+		if s.CutOffs == 0 {
+			t.Errorf("%s: expected some cut-offs", fen)
+		}
+	}
+}
+
+// Test engines with their own hash tables can search concurrently.
+func TestConcurrentEngines(t *testing.T) {
+	search := func(fen string) []Move {
+		pos, _ := PositionFromFEN(fen)
+		eng := NewEngine(pos, nil, Options{})
+		eng.HashTable = NewHashTable(1)
+		tc := NewFixedDepthTimeControl(pos, 5)
+		tc.Start(false)
+		_, pv := eng.Play(tc)
+		return pv
+	}
+
+	fens := TestFENs[:8]
+	want := make([][]Move, len(fens))
+	for i, fen := range fens {
+		want[i] = search(fen)
+	}
+
+	got := make([][]Move, len(fens))
+	done := make(chan struct{})
+	for i, fen := range fens {
+		go func(i int, fen string) {
+			got[i] = search(fen)
+			done <- struct{}{}
+		}(i, fen)
+	}
+	for range fens {
+		<-done
+	}
+
+	for i, fen := range fens {
+		if len(got[i]) == 0 || len(want[i]) == 0 || got[i][0] != want[i][0] {
+			t.Errorf("%s: got pv %v concurrently, wanted %v", fen, got[i], want[i])
+		}
+	}
+}
+
+func TestNodeLimit(t *testing.T) {
+	const limit = 50000
+	for _, fen := range TestFENs[:8] {
+		pos, _ := PositionFromFEN(fen)
+		tc := NewTimeControl(pos, false)
+		tc.Nodes = limit
+		tc.Start(false)
+		eng := NewEngine(pos, nil, Options{})
+		eng.HashTable = NewHashTable(1)
+		_, pv := eng.Play(tc)
+
+		if len(pv) == 0 {
+			t.Errorf("%s: expected a pv", fen)
+		}
+		if eng.Stats.Depth < 2 {
+			t.Errorf("%s: searched only to depth %d", fen, eng.Stats.Depth)
+		}
+		if eng.Stats.Nodes > limit+1000 {
+			t.Errorf("%s: searched %d nodes, expected at most about %d", fen, eng.Stats.Nodes, limit)
+		}
+	}
+}
+
+func TestContempt(t *testing.T) {
+	if tuning {
+		t.Skip("the tuner has no weights")
+	}
+	// Kxd2 is forced to draw.
+	const fen = "8/8/8/8/8/8/3q4/4K2k w - - 0 1"
+	for _, d := range []struct {
+		options Options
+		score   int32
+	}{
+		{Options{}, 0},
+		{Options{Contempt: 50}, -50},
+		{Options{Contempt: -20}, 20},
+		{Options{Contempt: 50, AnalyseMode: true}, 0},
+	} {
+		pos, _ := PositionFromFEN(fen)
+		tc := NewFixedDepthTimeControl(pos, 4)
+		tc.Start(false)
+		eng := NewEngine(pos, nil, d.options)
+		eng.HashTable = NewHashTable(1)
+		score, pv := eng.Play(tc)
+		if len(pv) == 0 || pv[0].UCI() != "e1d2" {
+			t.Errorf("%+v: got pv %v, expected e1d2", d.options, pv)
+		}
+		if score != d.score {
+			t.Errorf("%+v: got score %d, wanted %d", d.options, score, d.score)
+		}
+	}
+}
+
+func TestDeterministic(t *testing.T) {
+	search := func(eng *Engine, fen string) pvLogger {
+		log := &pvLogger{}
+		eng.Log = log
+		eng.SetPosition(nil)
+		pos, _ := PositionFromFEN(fen)
+		eng.SetPosition(pos)
+		tc := NewTimeControl(pos, false)
+		tc.Nodes = 20000
+		tc.Deterministic = true
+		tc.Start(false)
+		eng.Play(tc)
+		return *log
+	}
+
+	options := Options{Deterministic: true, Seed: 1, MultiPV: 2, HandicapLevel: 5}
+	eng := NewEngine(nil, nil, options)
+	eng.HashTable = NewHashTable(1)
+	for _, fen := range TestFENs[:4] {
+		// The tables of the previous search must not change the result.
+		want := search(eng, fen)
+		search(eng, FENKiwipete)
+		if got := search(eng, fen); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got different searches with the same engine", fen)
+		}
+
+		other := NewEngine(nil, nil, options)
+		other.HashTable = NewHashTable(1)
+		if got := search(other, fen); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got different searches with different engines", fen)
+		}
+	}
+}
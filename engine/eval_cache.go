@@ -0,0 +1,26 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package engine
+
+// evalEntry is a cache entry.
+type evalEntry struct {
+	lock  uint64 // Zobrist key of the position
+	score int32  // static score from White's POV
+}
+
+// evalTable implements a fixed size cache of the static evaluation.
+type evalTable [1 << 14]evalEntry
+
+// put puts a new entry in the cache.
+func (c *evalTable) put(lock uint64, score int32) {
+	indx := lock & uint64(len(*c)-1)
+	c[indx] = evalEntry{lock, score}
+}
+
+// get gets an entry from the cache.
+func (c *evalTable) get(lock uint64) (int32, bool) {
+	indx := lock & uint64(len(*c)-1)
+	return c[indx].score, c[indx].lock == lock
+}
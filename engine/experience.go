@@ -0,0 +1,254 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// experience.go implements the experience file which remembers
+// the searches and the outcomes of the positions from past games.
+//
+// After each game the engine records the root positions it searched
+// together with the result of the game. Before a new game the entries
+// are merged into the transposition table, with the score adjusted
+// towards the results of the games, so the engine avoids the lines it
+// lost before and repeats the lines it won.
+//
+// The experience file is little endian:
+//
+//   magic   [4]byte  "ZXP1"
+//   size    uint32   number of entries
+//   entries [size]ExperienceEntry, sorted by key
+
+package engine
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	. "bitbucket.org/zurichess/board"
+)
+
+const (
+	// DefaultExperienceSize is the default maximum number of positions in the experience.
+	DefaultExperienceSize = 1 << 16
+
+	experienceMagic   = "ZXP1"
+	experienceMaxSize = 1 << 24 // maximum number of entries in a file
+	experienceBonus   = 50      // score bonus for won positions, in centipawns
+)
+
+// ExperienceEntry is the experience of a position.
+type ExperienceEntry struct {
+	Key    uint64 // Zobrist key of the position
+	Move   Move   // best move found
+	Score  int16  // score from the side to move POV
+	Depth  int8   // search depth
+	Wins   uint16 // number of games won by the side to move
+	Draws  uint16 // number of games drawn
+	Losses uint16 // number of games lost by the side to move
+}
+
+// Games returns the number of games that reached the position.
+func (e *ExperienceEntry) Games() int {
+	return int(e.Wins) + int(e.Draws) + int(e.Losses)
+}
+
+// adjustedScore returns the score moved towards the results of the games.
+// Mate scores are not adjusted.
+func (e *ExperienceEntry) adjustedScore() int32 {
+	score := int32(e.Score)
+	if score <= KnownLossScore || score >= KnownWinScore || e.Games() == 0 {
+		return score
+	}
+	score += experienceBonus * (int32(e.Wins) - int32(e.Losses)) / int32(e.Games())
+	return min(max(score, KnownLossScore+1), KnownWinScore-1)
+}
+
+// Experience stores the experience of positions from past games.
+type Experience struct {
+	MaxSize int // maximum number of positions kept by Prune
+
+	entries map[uint64]*ExperienceEntry
+}
+
+// NewExperience returns an empty experience keeping at most maxSize positions.
+func NewExperience(maxSize int) *Experience {
+	return &Experience{
+		MaxSize: maxSize,
+		entries: make(map[uint64]*ExperienceEntry),
+	}
+}
+
+// ReadExperience reads an experience from r.
+func ReadExperience(r io.Reader, maxSize int) (*Experience, error) {
+	var header struct {
+		Magic [4]byte
+		Size  uint32
+	}
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return nil, fmt.Errorf("experience: %v", err)
+	}
+	if string(header.Magic[:]) != experienceMagic {
+		return nil, errors.New("experience: not an experience file")
+	}
+	if header.Size > experienceMaxSize {
+		return nil, fmt.Errorf("experience: too many entries %d", header.Size)
+	}
+
+	entries := make([]ExperienceEntry, header.Size)
+	if err := binary.Read(r, binary.LittleEndian, entries); err != nil {
+		return nil, fmt.Errorf("experience: %v", err)
+	}
+	x := NewExperience(maxSize)
+	for i := range entries {
+		x.entries[entries[i].Key] = &entries[i]
+	}
+	return x, nil
+}
+
+// ReadExperienceFile reads an experience from the file name.
+// If the file doesn't exist, returns an empty experience.
+func ReadExperienceFile(name string, maxSize int) (*Experience, error) {
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return NewExperience(maxSize), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadExperience(bufio.NewReader(f), maxSize)
+}
+
+// Write writes x to w in the experience file format.
+func (x *Experience) Write(w io.Writer) error {
+	entries := x.Entries()
+	bw := bufio.NewWriter(w)
+	bw.WriteString(experienceMagic)
+	for _, data := range []interface{}{uint32(len(entries)), entries} {
+		if err := binary.Write(bw, binary.LittleEndian, data); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// WriteFile writes x to the file name.
+func (x *Experience) WriteFile(name string) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := x.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Len returns the number of positions in x.
+func (x *Experience) Len() int {
+	return len(x.entries)
+}
+
+// Get returns the experience of the position with Zobrist key.
+func (x *Experience) Get(key uint64) (ExperienceEntry, bool) {
+	if e, ok := x.entries[key]; ok {
+		return *e, true
+	}
+	return ExperienceEntry{}, false
+}
+
+// Entries returns all entries sorted by key.
+func (x *Experience) Entries() []ExperienceEntry {
+	entries := make([]ExperienceEntry, 0, len(x.entries))
+	for _, e := range x.entries {
+		entries = append(entries, *e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+	return entries
+}
+
+// Record adds a search of the position with Zobrist key to x.
+// result is the result of the game from the side to move POV:
+// 1 for a win, 0 for a draw and -1 for a loss.
+// The deepest search of each position is kept.
+func (x *Experience) Record(key uint64, move Move, score, depth int32, result int) {
+	e, ok := x.entries[key]
+	if !ok {
+		e = &ExperienceEntry{Key: key, Depth: -1}
+		x.entries[key] = e
+	}
+	if depth >= int32(e.Depth) {
+		e.Move, e.Score, e.Depth = move, int16(score), int8(depth)
+	}
+	switch {
+	case result > 0 && e.Wins < 1<<16-1:
+		e.Wins++
+	case result == 0 && e.Draws < 1<<16-1:
+		e.Draws++
+	case result < 0 && e.Losses < 1<<16-1:
+		e.Losses++
+	}
+}
+
+// Prune removes the positions with searches shallower than minDepth
+// and then keeps at most MaxSize positions, preferring the positions
+// reached in more games and the deeper searches.
+func (x *Experience) Prune(minDepth int) {
+	for key, e := range x.entries {
+		if int(e.Depth) < minDepth {
+			delete(x.entries, key)
+		}
+	}
+	if len(x.entries) <= x.MaxSize {
+		return
+	}
+
+	entries := x.Entries()
+	sort.SliceStable(entries, func(i, j int) bool {
+		if gi, gj := entries[i].Games(), entries[j].Games(); gi != gj {
+			return gi > gj
+		}
+		return entries[i].Depth > entries[j].Depth
+	})
+	for _, e := range entries[x.MaxSize:] {
+		delete(x.entries, e.Key)
+	}
+}
+
+// Merge puts the experience into the transposition table ht.
+// Entries with a deeper search already in ht are kept.
+//
+// The score adjusted for the results was never searched, so it is
+// stored only as the bound that the searched score satisfies, and one
+// ply shallower than the search so the next search of the position
+// verifies it. The best move is still searched first.
+func (x *Experience) Merge(ht *HashTable) {
+	for _, e := range x.entries {
+		if e.Depth <= 0 {
+			continue
+		}
+		depth := e.Depth - 1
+		if old := ht.getKey(e.Key); old.kind != 0 && old.depth >= depth {
+			continue
+		}
+		kind, score := exact, e.adjustedScore()
+		if score > int32(e.Score) {
+			kind = failedLow
+		} else if score < int32(e.Score) {
+			kind = failedHigh
+		}
+		ht.putKey(e.Key, hashEntry{
+			kind:  kind,
+			score: int16(score),
+			depth: depth,
+			move:  e.Move,
+		})
+	}
+}
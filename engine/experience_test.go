@@ -0,0 +1,116 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package engine
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	. "bitbucket.org/zurichess/board"
+)
+
+func TestExperienceRecord(t *testing.T) {
+	x := NewExperience(10)
+	x.Record(1, Move(10), 30, 8, 1)
+	x.Record(1, Move(11), 40, 6, 0)   // shallower search is ignored
+	x.Record(1, Move(12), -20, 9, -1) // deeper search replaces the move
+	x.Record(2, Move(13), 0, 5, 0)
+
+	want := ExperienceEntry{Key: 1, Move: Move(12), Score: -20, Depth: 9, Wins: 1, Draws: 1, Losses: 1}
+	if got, ok := x.Get(1); !ok || got != want {
+		t.Errorf("got %+v, wanted %+v", got, want)
+	}
+	if got := x.Len(); got != 2 {
+		t.Errorf("got %d positions, wanted 2", got)
+	}
+	if _, ok := x.Get(3); ok {
+		t.Errorf("got experience for an unknown position")
+	}
+}
+
+func TestExperienceReadWrite(t *testing.T) {
+	want := NewExperience(100)
+	for i := 0; i < 50; i++ {
+		want.Record(uint64(i)*0x9e3779b97f4a7c15, Move(i), int32(i*7-100), int32(i%20), i%3-1)
+	}
+	buf := &bytes.Buffer{}
+	if err := want.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadExperience(bytes.NewReader(buf.Bytes()), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Entries(), want.Entries()) {
+		t.Errorf("experience changed after writing and reading")
+	}
+
+	// Corrupted files.
+	data := buf.Bytes()
+	if _, err := ReadExperience(bytes.NewReader(data[:len(data)-1]), 100); err == nil {
+		t.Errorf("expected an error for a truncated experience")
+	}
+	data[0] = 'X'
+	if _, err := ReadExperience(bytes.NewReader(data), 100); err == nil {
+		t.Errorf("expected an error for a bad magic")
+	}
+}
+
+func TestExperiencePrune(t *testing.T) {
+	x := NewExperience(2)
+	x.Record(1, NullMove, 0, 3, 0)
+	x.Record(2, NullMove, 0, 10, 0)
+	x.Record(3, NullMove, 0, 5, 0)
+	x.Record(3, NullMove, 0, 5, 1)
+	x.Record(4, NullMove, 0, 1, 0)
+
+	// Position 4 is too shallow, position 1 is shallower than 2.
+	x.Prune(2)
+	var keys []uint64
+	for _, e := range x.Entries() {
+		keys = append(keys, e.Key)
+	}
+	if want := []uint64{2, 3}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("got positions %v after pruning, wanted %v", keys, want)
+	}
+}
+
+func TestExperienceMerge(t *testing.T) {
+	pos, _ := PositionFromFEN(FENStartPos)
+	move, _ := pos.UCIToMove("d2d4")
+	x := NewExperience(10)
+	x.Record(pos.Zobrist(), move, 20, 12, 1)
+	x.Record(pos.Zobrist(), move, 20, 12, 1)
+
+	eng := NewEngine(pos, nil, Options{})
+	eng.HashTable = NewHashTable(1)
+	x.Merge(eng.HashTable)
+	entry := eng.retrieveHash()
+	if entry.move != move || entry.depth != 11 || entry.kind != failedLow {
+		t.Errorf("got hash entry %+v, wanted upper bound with move %v at depth 11", entry, move)
+	}
+	if want := int16(20 + experienceBonus); entry.score != want {
+		t.Errorf("got score %d, wanted %d", entry.score, want)
+	}
+
+	// Lost positions are stored as lower bounds.
+	x = NewExperience(10)
+	x.Record(pos.Zobrist(), move, 20, 12, -1)
+	eng.HashTable.Clear()
+	x.Merge(eng.HashTable)
+	if entry := eng.retrieveHash(); entry.kind != failedHigh || entry.score != 20-experienceBonus {
+		t.Errorf("got hash entry %+v, wanted lower bound %d", entry, 20-experienceBonus)
+	}
+
+	// Deeper entries already in the hash table are kept.
+	x.Record(pos.Zobrist(), NullMove, 0, 3, 0)
+	eng.HashTable.Clear()
+	eng.updateHash(exact, 20, 0, NullMove, 0)
+	x.Merge(eng.HashTable)
+	if entry := eng.retrieveHash(); entry.depth != 20 {
+		t.Errorf("got depth %d, wanted 20", entry.depth)
+	}
+}
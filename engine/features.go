@@ -56,6 +56,24 @@ const (
 	fPassedPawnRank             featureType = 178
 	fKingEnemyPassedPawnTropism featureType = 186
 	fKingPassedPawnTropism      featureType = 194
+	fKingAttackUnits            featureType = 202
+	fSafeChecks                 featureType = 206
+	fKingZoneOpenFiles          featureType = 210
+	fKingZoneSemiOpenFiles      featureType = 211
+	fHangingPieces              featureType = 212
+	fLoosePieces                featureType = 213
+	fMinorsAttackMajors         featureType = 214
+	fRooksAttackQueens          featureType = 215
+	fKnightOutposts             featureType = 216
+	fBishopOutposts             featureType = 217
+	fSpace                      featureType = 218
+	fKnightSquare               featureType = 219
+	fBishopSquare               featureType = 347
+	fRookSquare                 featureType = 475
+	fQueenSquare                featureType = 603
+	fKingSquare                 featureType = 731
+	fImbalanceOurs              featureType = 795
+	fImbalanceTheirs            featureType = 810
 )
 
 func getFeatureStart(feature featureType, num int) int {
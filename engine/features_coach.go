@@ -35,6 +35,15 @@ const (
 	fKingFile   featureType = "KingFile"
 	fKingRank   featureType = "KingRank"
 
+	// Full PSqT for each figure from white's POV, used instead of the
+	// coordinates when built with the fullpsqt tag. Except for the king,
+	// there is one table for each king bucket.
+	fKnightSquare featureType = "KnightSquare"
+	fBishopSquare featureType = "BishopSquare"
+	fRookSquare   featureType = "RookSquare"
+	fQueenSquare  featureType = "QueenSquare"
+	fKingSquare   featureType = "KingSquare"
+
 	// Mobility of each figure.
 	fKnightAttack featureType = "KnightAttack"
 	fBishopAttack featureType = "BishopAttack"
@@ -78,6 +87,32 @@ const (
 	fKingShelterFront featureType = "KingShelterFront"
 	// Pawn in front of the king, including adjacent files.
 	fKingShelterFar featureType = "KingShelterFar"
+	// Attacks of each figure on the enemy king's area.
+	fKingAttackUnits featureType = "KingAttackUnits"
+	// Safe checks by each figure.
+	fSafeChecks featureType = "SafeChecks"
+	// Open and semi-open files around the enemy king.
+	fKingZoneOpenFiles     featureType = "KingZoneOpenFiles"
+	fKingZoneSemiOpenFiles featureType = "KingZoneSemiOpenFiles"
+
+	// Threats.
+	// Enemy pieces attacked and not defended.
+	fHangingPieces featureType = "HangingPieces"
+	// Pieces not defended.
+	fLoosePieces featureType = "LoosePieces"
+	// Majors attacked by minors and queens attacked by rooks.
+	fMinorsAttackMajors featureType = "MinorsAttackMajors"
+	fRooksAttackQueens  featureType = "RooksAttackQueens"
+
+	// Outposts and space.
+	fKnightOutposts featureType = "KnightOutposts"
+	fBishopOutposts featureType = "BishopOutposts"
+	fSpace          featureType = "Space"
+
+	// Material imbalance: products of our figure counts
+	// with our and their figure counts.
+	fImbalanceOurs   featureType = "ImbalanceOurs"
+	fImbalanceTheirs featureType = "ImbalanceTheirs"
 )
 
 var (
@@ -0,0 +1,69 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !coach
+
+package engine
+
+import (
+	"testing"
+
+	. "bitbucket.org/zurichess/board"
+)
+
+// featureValue returns the value of the i-th input of feature for us in pos.
+func featureValue(pos *Position, us Color, feature featureType, i int) int32 {
+	w := &Weights[int(feature)+i]
+	saved := *w
+	defer func() { *w = saved }()
+
+	*w = Score{}
+	before := evaluatePosition(pos, new(pawnsTable), new(materialTable)).Accum[us].M
+	*w = Score{M: 1}
+	after := evaluatePosition(pos, new(pawnsTable), new(materialTable)).Accum[us].M
+	return after - before
+}
+
+func TestFeatures(t *testing.T) {
+	data := []struct {
+		fen     string
+		us      Color
+		feature featureType
+		i       int
+		want    int32
+	}{
+		{FENStartPos, White, fSpace, 0, 8},
+		{FENStartPos, Black, fSpace, 0, 8},
+		{FENStartPos, White, fKingZoneOpenFiles, 0, 0},
+		{FENStartPos, White, fLoosePieces, 0, 2}, // rooks
+		// After 1. e4 the squares behind the pawn count twice.
+		{"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1", White, fSpace, 0, 10},
+		{"4k3/8/8/8/8/8/8/4K3 w - - 0 1", White, fKingZoneOpenFiles, 0, 3},
+		{"4k3/3pp3/8/8/8/8/4PP2/4K3 w - - 0 1", White, fKingZoneOpenFiles, 0, 0},
+		{"4k3/3pp3/8/8/8/8/4PP2/4K3 w - - 0 1", White, fKingZoneSemiOpenFiles, 0, 1},
+		{"4k3/3pp3/8/8/8/8/4PP2/4K3 w - - 0 1", Black, fKingZoneSemiOpenFiles, 0, 1},
+		// The knight on d5 is hanging and loose, the rook is defended by the king.
+		{"4k3/8/8/3n4/8/8/8/3RK3 w - - 0 1", White, fHangingPieces, 0, 1},
+		{"4k3/8/8/3n4/8/8/8/3RK3 w - - 0 1", Black, fLoosePieces, 0, 1},
+		{"4k3/8/8/3n4/8/8/8/3RK3 w - - 0 1", White, fLoosePieces, 0, 0},
+		{"3qk3/8/8/8/8/8/8/3RK3 w - - 0 1", White, fRooksAttackQueens, 0, 1},
+		{"3qk3/8/8/8/8/8/8/3RK3 w - - 0 1", White, fHangingPieces, 0, 0},
+		{"4k3/8/8/2r5/8/3N4/8/4K3 w - - 0 1", White, fMinorsAttackMajors, 0, 1},
+		// The knight on e5 is on an outpost, the bishop on c5 can be attacked by b7-b6.
+		{"4k3/1p6/8/2B1N3/3P4/8/8/4K3 w - - 0 1", White, fKnightOutposts, 0, 1},
+		{"4k3/1p6/8/2B1N3/3P4/8/8/4K3 w - - 0 1", White, fBishopOutposts, 0, 0},
+		// The queen on d1 attacks d7 and d8, and can check safely from a4, e2 and h5.
+		{"4k3/8/8/8/8/8/8/3QK3 w - - 0 1", White, fKingAttackUnits, int(Queen - Knight), 2},
+		{"4k3/8/8/8/8/8/8/3QK3 w - - 0 1", White, fSafeChecks, int(Queen - Knight), 3},
+		{"4k3/8/8/8/8/5N2/8/4K3 w - - 0 1", White, fSafeChecks, int(Knight - Knight), 0},
+		{"4k3/8/8/8/8/8/5N2/4K3 w - - 0 1", White, fSafeChecks, int(Knight - Knight), 0},
+		{"4k3/8/8/8/4N3/8/8/4K3 w - - 0 1", White, fSafeChecks, int(Knight - Knight), 2},
+	}
+	for _, d := range data {
+		pos, _ := PositionFromFEN(d.fen)
+		if got := featureValue(pos, d.us, d.feature, d.i); got != d.want {
+			t.Errorf("%s: got %d for feature %d+%d, wanted %d", d.fen, got, d.feature, d.i, d.want)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !fullpsqt
+
+package engine
+
+// fullPSQT is true if the figures are evaluated with full piece square
+// tables instead of separate file and rank weights.
+const fullPSQT = false
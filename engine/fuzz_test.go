@@ -0,0 +1,33 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Fuzzing needs Go 1.18 or newer.
+
+// +build go1.18
+
+package engine
+
+import (
+	"testing"
+
+	. "bitbucket.org/zurichess/board"
+	. "bitbucket.org/zurichess/zurichess/internal/testdata"
+)
+
+// FuzzEvaluate checks the evaluation of random positions.
+// Run with go test -fuzz FuzzEvaluate.
+func FuzzEvaluate(f *testing.F) {
+	for _, fen := range TestFENs {
+		pos, _ := PositionFromFEN(fen)
+		f.Add(positionToBytes(pos))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		pos := positionFromBytes(data)
+		if pos == nil {
+			t.Skip()
+		}
+		checkSymmetry(t, pos.String())
+		checkPawnsCache(t, new(pawnsTable), pos)
+	})
+}
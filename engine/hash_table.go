@@ -114,7 +114,12 @@ func (ht *HashTable) prefetch(pos *Position) {
 
 // put puts a new entry in the database.
 func (ht *HashTable) put(pos *Position, entry hashEntry) {
-	lock, key0, key1 := split(pos.Zobrist(), ht.mask)
+	ht.putKey(pos.Zobrist(), entry)
+}
+
+// putKey puts a new entry for the position with Zobrist key.
+func (ht *HashTable) putKey(key uint64, entry hashEntry) {
+	lock, key0, key1 := split(key, ht.mask)
 	entry.lock = lock
 
 	if e := &ht.table[key0]; e.lock == lock || e.kind == 0 || e.depth >= entry.depth {
@@ -130,7 +135,12 @@ func (ht *HashTable) put(pos *Position, entry hashEntry) {
 // from a different table. However, these errors are not common because
 // we use 32-bit lock + log_2(len(ht.table)) bits to avoid collisions.
 func (ht *HashTable) get(pos *Position) hashEntry {
-	lock, key0, key1 := split(pos.Zobrist(), ht.mask)
+	return ht.getKey(pos.Zobrist())
+}
+
+// getKey returns the hash entry for the position with Zobrist key.
+func (ht *HashTable) getKey(key uint64) hashEntry {
+	lock, key0, key1 := split(key, ht.mask)
 	if ht.table[key0].lock == lock {
 		return ht.table[key0]
 	}
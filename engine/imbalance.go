@@ -0,0 +1,116 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// imbalance.go implements the evaluation that depends only on the material.
+//
+// The value of a figure depends on the other figures on the board,
+// e.g. knights are better with many pawns, so the imbalance is evaluated
+// by quadratic terms: the products of our figure counts with our and
+// their figure counts. The material also decides the scale of some
+// drawish endgames. Both are cached in a table keyed by the piece counts.
+
+package engine
+
+import . "bitbucket.org/zurichess/board"
+
+// Number of the imbalance terms of each side.
+const (
+	numImbalanceOurs   = 15 // pairs of our figures
+	numImbalanceTheirs = 25 // our figure and their figure
+)
+
+// materialEntry is a cache entry.
+type materialEntry struct {
+	lock  uint64
+	white Accum
+	black Accum
+	scale int32
+}
+
+// materialTable implements a fixed size cache.
+type materialTable [1 << 10]materialEntry
+
+// put puts a new entry in the cache.
+func (c *materialTable) put(lock uint64, white, black Accum, scale int32) {
+	indx := lock & uint64(len(*c)-1)
+	c[indx] = materialEntry{lock, white, black, scale}
+}
+
+// get gets an entry from the cache.
+func (c *materialTable) get(lock uint64) (Accum, Accum, int32, bool) {
+	indx := lock & uint64(len(*c)-1)
+	return c[indx].white, c[indx].black, c[indx].scale, c[indx].lock == lock
+}
+
+// load evaluates the material of position, using the cache if possible.
+// Returns the imbalance of each side and the scale of the score.
+func (c *materialTable) load(pos *Position) (Accum, Accum, int32) {
+	wm, bm := materialOf(pos, White), materialOf(pos, Black)
+	h := murmurSeed[NoColor]
+	h = murmurMix(h, uint64(wm))
+	h = murmurMix(h, uint64(bm))
+	white, black, scale, ok := c.get(h)
+	if !ok {
+		white = evaluateImbalance(wm, bm)
+		black = evaluateImbalance(bm, wm)
+		scale = materialScale(wm, bm)
+		c.put(h, white, black, scale)
+	}
+	return white, black, scale
+}
+
+// evaluateImbalance evaluates the interactions of our figures
+// with our and their figures.
+func evaluateImbalance(ours, theirs material) (accum Accum) {
+	k := 0
+	for i := Pawn; i <= Queen; i++ {
+		for j := Pawn; j <= i; j++ {
+			groupByTable(fImbalanceOurs, numImbalanceOurs, k, ours.count(i)*ours.count(j), &accum)
+			k++
+		}
+	}
+	k = 0
+	for i := Pawn; i <= Queen; i++ {
+		for j := Pawn; j <= Queen; j++ {
+			groupByTable(fImbalanceTheirs, numImbalanceTheirs, k, ours.count(i)*theirs.count(j), &accum)
+			k++
+		}
+	}
+	return accum
+}
+
+// materialValue is the value of the figures used to
+// recognize the drawish endgames, in pawns.
+var materialValue = [FigureArraySize]int32{Knight: 3, Bishop: 3, Rook: 5, Queen: 9}
+
+// value returns the value of the figures, except pawns, in m.
+func (m material) value() int32 {
+	v := int32(0)
+	for fig := Knight; fig <= Queen; fig++ {
+		v += m.count(fig) * materialValue[fig]
+	}
+	return v
+}
+
+// materialScale returns the scale of the score for the material
+// of White, wm, and of Black, bm.
+//
+// Without pawns a side that is up at most a minor piece can rarely
+// win, e.g. KRB vs KR, and with only a minor it cannot win at all.
+func materialScale(wm, bm material) int32 {
+	if wm.count(Pawn) != 0 || bm.count(Pawn) != 0 {
+		return scaleNormal
+	}
+	strong, weak := wm.value(), bm.value()
+	if strong < weak {
+		strong, weak = weak, strong
+	}
+	if strong == weak || strong-weak > materialValue[Bishop] {
+		return scaleNormal
+	}
+	if strong < materialValue[Rook] {
+		return scaleDraw
+	}
+	return scaleHard
+}
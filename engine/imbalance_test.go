@@ -0,0 +1,65 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package engine
+
+import (
+	"reflect"
+	"testing"
+
+	. "bitbucket.org/zurichess/board"
+)
+
+func TestMaterialCount(t *testing.T) {
+	pos, _ := PositionFromFEN(FENStartPos)
+	m := materialOf(pos, White)
+	for fig, want := range map[Figure]int32{Pawn: 8, Knight: 2, Bishop: 2, Rook: 2, Queen: 1} {
+		if got := m.count(fig); got != want {
+			t.Errorf("got %d %v, wanted %d", got, fig, want)
+		}
+	}
+}
+
+func TestMaterialScale(t *testing.T) {
+	data := []struct {
+		fen   string
+		scale int32
+	}{
+		{"4k3/8/8/8/8/8/8/2RBK3 w - - 0 1", scaleNormal},  // KRB vs K
+		{"4k3/8/8/8/8/8/8/3NK3 w - - 0 1", scaleDraw},     // KN vs K
+		{"4k3/8/8/8/8/8/8/3BK3 w - - 0 1", scaleDraw},     // KB vs K
+		{"3rk3/8/8/8/8/8/8/2RBK3 w - - 0 1", scaleHard},   // KRB vs KR
+		{"3nk3/8/8/8/8/8/8/2RBK3 w - - 0 1", scaleNormal}, // KRB vs KN
+		{"3rk3/8/8/8/8/8/8/3QK3 w - - 0 1", scaleNormal},  // KQ vs KR
+		{"2nrk3/8/8/8/8/8/8/3RK3 b - - 0 1", scaleHard},   // KR vs KRN
+		{"3rk3/8/8/8/8/8/P7/2RBK3 w - - 0 1", scaleNormal},
+	}
+	for _, d := range data {
+		pos, err := PositionFromFEN(d.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if scale := materialScale(materialOf(pos, White), materialOf(pos, Black)); scale != d.scale {
+			t.Errorf("%s: got scale %d, wanted %d", d.fen, scale, d.scale)
+		}
+	}
+}
+
+func TestMaterialCache(t *testing.T) {
+	var materials materialTable
+	for _, fen := range testPositions() {
+		pos, _ := PositionFromFEN(fen)
+		wm, bm := materialOf(pos, White), materialOf(pos, Black)
+		white, black, scale := materials.load(pos)
+		if want := evaluateImbalance(wm, bm); !reflect.DeepEqual(white, want) {
+			t.Errorf("%v: got White imbalance %v, wanted %v", pos, white, want)
+		}
+		if want := evaluateImbalance(bm, wm); !reflect.DeepEqual(black, want) {
+			t.Errorf("%v: got Black imbalance %v, wanted %v", pos, black, want)
+		}
+		if want := materialScale(wm, bm); scale != want {
+			t.Errorf("%v: got scale %d, wanted %d", pos, scale, want)
+		}
+	}
+}
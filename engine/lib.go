@@ -21,6 +21,17 @@ func groupByBucket(feature featureType, n int, limit int, accum *Accum) {
 	accum.add(Weights[start+n])
 }
 
+// groupByFigure adds the weight of fig, a figure from Knight to Queen, n times.
+func groupByFigure(feature featureType, fig Figure, n int32, accum *Accum) {
+	groupByTable(feature, 4, int(fig-Knight), n, accum)
+}
+
+// groupByTable adds the weight at index of a feature with num weights n times.
+func groupByTable(feature featureType, num, index int, n int32, accum *Accum) {
+	start := getFeatureStart(feature, num)
+	accum.addN(Weights[start+index], n)
+}
+
 func groupByBoard(feature featureType, bb Bitboard, accum *Accum) {
 	groupByCount(feature, bb.Count(), accum)
 }
@@ -48,6 +59,19 @@ func groupByRankSq(feature featureType, us Color, sq Square, accum *Accum) {
 	groupByBucket(feature, sq.POV(us).Rank(), 8, accum)
 }
 
+// groupByFigureSq evaluates a figure of us on sq. With full piece square
+// tables the square is evaluated in the table of bucket, otherwise
+// the file and the rank are evaluated separately.
+func groupByFigureSq(file, rank, square featureType, us Color, sq Square, bucket int, accum *Accum) {
+	if fullPSQT {
+		start := getFeatureStart(square, 64*kingBuckets)
+		accum.add(Weights[start+64*bucket+int(sq.POV(us))])
+		return
+	}
+	groupByFileSq(file, us, sq, accum)
+	groupByRankSq(rank, us, sq, accum)
+}
+
 func groupByRank(feature featureType, us Color, bb Bitboard, accum *Accum) {
 	for bb != BbEmpty {
 		sq := bb.Pop()
@@ -0,0 +1,174 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package engine
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	. "bitbucket.org/zurichess/board"
+)
+
+// LogEvent is an event written by JSONLogger.
+type LogEvent struct {
+	Event     string   `json:"event"`               // begin, pv, currmove, rootmove or end
+	Time      int64    `json:"time"`                // milliseconds since the search started
+	FEN       string   `json:"fen,omitempty"`       // searched position, only for begin
+	Depth     int32    `json:"depth,omitempty"`     // search depth
+	SelDepth  int32    `json:"seldepth,omitempty"`  // selective search depth
+	MultiPV   int      `json:"multipv,omitempty"`   // 1-based index of the principal variation
+	ScoreType string   `json:"scoretype,omitempty"` // cp or mate
+	Score     *int32   `json:"score,omitempty"`     // score in centipawns or moves to mate
+	Nodes     uint64   `json:"nodes,omitempty"`     // nodes searched
+	NPS       uint64   `json:"nps,omitempty"`       // nodes searched per second
+	PV        []string `json:"pv,omitempty"`        // principal variation in UCI format
+	SAN       []string `json:"san,omitempty"`       // principal variation in SAN
+	Move      string   `json:"move,omitempty"`      // current or root move in UCI format
+	MoveNum   int      `json:"movenum,omitempty"`   // 1-based index of the current or root move
+	Bound     string   `json:"bound,omitempty"`     // lowerbound or upperbound, only for inexact root moves
+	Share     float64  `json:"share,omitempty"`     // fraction of the root nodes searched for the root move
+}
+
+// JSONLogger is a Logger that writes one JSON object (a LogEvent) per line.
+// JSONLogger is safe for concurrent use, but the events of
+// concurrent searches are interleaved.
+type JSONLogger struct {
+	mu    sync.Mutex
+	enc   *json.Encoder
+	eng   *Engine
+	start time.Time
+}
+
+// NewJSONLogger returns a logger writing the searches of eng to w.
+// The position of eng is used to convert the principal variations
+// to SAN, so eng.Position must be the root of the search.
+func NewJSONLogger(w io.Writer, eng *Engine) *JSONLogger {
+	return &JSONLogger{enc: json.NewEncoder(w), eng: eng}
+}
+
+// setScore sets the score of ev in centipawns or moves to mate.
+func (ev *LogEvent) setScore(score int32) {
+	if score > KnownWinScore {
+		score = (MateScore - score + 1) / 2
+		ev.ScoreType = "mate"
+	} else if score < KnownLossScore {
+		score = (MatedScore - score) / 2
+		ev.ScoreType = "mate"
+	} else {
+		ev.ScoreType = "cp"
+	}
+	ev.Score = &score
+}
+
+// write writes ev setting the time since the search started.
+func (jl *JSONLogger) write(ev *LogEvent) {
+	ev.Time = int64(time.Now().Sub(jl.start) / time.Millisecond)
+	jl.enc.Encode(ev)
+}
+
+func (jl *JSONLogger) BeginSearch() {
+	jl.mu.Lock()
+	defer jl.mu.Unlock()
+	jl.start = time.Now()
+	jl.write(&LogEvent{Event: "begin", FEN: jl.eng.Position.String()})
+}
+
+func (jl *JSONLogger) EndSearch() {
+	jl.mu.Lock()
+	defer jl.mu.Unlock()
+	stats := &jl.eng.Stats
+	jl.write(&LogEvent{Event: "end", Depth: stats.Depth, SelDepth: stats.SelDepth, Nodes: stats.Nodes})
+}
+
+func (jl *JSONLogger) PrintPV(stats Stats, multiPV int, score int32, pv []Move) {
+	jl.mu.Lock()
+	defer jl.mu.Unlock()
+
+	ev := &LogEvent{
+		Event:    "pv",
+		Depth:    stats.Depth,
+		SelDepth: stats.SelDepth,
+		MultiPV:  multiPV,
+		Nodes:    stats.Nodes,
+		PV:       make([]string, len(pv)),
+		SAN:      PVToSAN(jl.eng.Position, pv),
+	}
+	ev.setScore(score)
+	if elapsed := time.Now().Sub(jl.start); elapsed > 0 {
+		ev.NPS = stats.Nodes * uint64(time.Second) / uint64(elapsed)
+	}
+	for i, m := range pv {
+		ev.PV[i] = m.UCI()
+	}
+	jl.write(ev)
+}
+
+func (jl *JSONLogger) CurrMove(depth int, move Move, num int) {
+	jl.mu.Lock()
+	defer jl.mu.Unlock()
+	jl.write(&LogEvent{Event: "currmove", Depth: int32(depth), Move: move.UCI(), MoveNum: num})
+}
+
+func (jl *JSONLogger) PrintRootMoves(stats Stats, moves []RootMove) {
+	jl.mu.Lock()
+	defer jl.mu.Unlock()
+	for i, rm := range moves {
+		ev := &LogEvent{
+			Event:   "rootmove",
+			Depth:   rm.Depth,
+			Nodes:   rm.Nodes,
+			Move:    rm.Move.UCI(),
+			MoveNum: i + 1,
+			Share:   rm.Share,
+		}
+		if rm.Bound != "exact" {
+			ev.Bound = rm.Bound
+		}
+		ev.setScore(rm.Score)
+		jl.write(ev)
+	}
+}
+
+// multiLogger duplicates the events to several loggers.
+type multiLogger []Logger
+
+// MultiLogger returns a logger that duplicates its events to all loggers.
+func MultiLogger(loggers ...Logger) Logger {
+	return multiLogger(loggers)
+}
+
+func (ml multiLogger) BeginSearch() {
+	for _, l := range ml {
+		l.BeginSearch()
+	}
+}
+
+func (ml multiLogger) EndSearch() {
+	for _, l := range ml {
+		l.EndSearch()
+	}
+}
+
+func (ml multiLogger) PrintPV(stats Stats, multiPV int, score int32, pv []Move) {
+	for _, l := range ml {
+		l.PrintPV(stats, multiPV, score, pv)
+	}
+}
+
+func (ml multiLogger) CurrMove(depth int, move Move, num int) {
+	for _, l := range ml {
+		l.CurrMove(depth, move, num)
+	}
+}
+
+func (ml multiLogger) PrintRootMoves(stats Stats, moves []RootMove) {
+	for _, l := range ml {
+		if rl, ok := l.(RootMovesLogger); ok {
+			rl.PrintRootMoves(stats, moves)
+		}
+	}
+}
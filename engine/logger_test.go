@@ -0,0 +1,62 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	. "bitbucket.org/zurichess/board"
+	. "bitbucket.org/zurichess/zurichess/internal/testdata"
+)
+
+func TestJSONLogger(t *testing.T) {
+	for _, d := range MateIn1[:4] {
+		buf := &bytes.Buffer{}
+		pos, _ := PositionFromFEN(d.FEN)
+		eng := NewEngine(pos, nil, Options{MultiPV: 2})
+		eng.Log = MultiLogger(&NulLogger{}, NewJSONLogger(buf, eng))
+		tc := NewFixedDepthTimeControl(pos, 3)
+		tc.Start(false)
+		eng.Play(tc)
+
+		var events []LogEvent
+		scan := bufio.NewScanner(buf)
+		for scan.Scan() {
+			var ev LogEvent
+			if err := json.Unmarshal(scan.Bytes(), &ev); err != nil {
+				t.Fatalf("cannot parse %q: %v", scan.Text(), err)
+			}
+			events = append(events, ev)
+		}
+
+		if len(events) < 3 || events[0].Event != "begin" || events[len(events)-1].Event != "end" {
+			t.Fatalf("%s: got %d events, wanted begin, pvs and end", d.FEN, len(events))
+		}
+		if events[0].FEN != d.FEN {
+			t.Errorf("got fen %s, wanted %s", events[0].FEN, d.FEN)
+		}
+
+		var last *LogEvent
+		for i, ev := range events[1 : len(events)-1] {
+			if ev.Event == "currmove" && ev.Move != "" && ev.MoveNum > 0 {
+				continue
+			}
+			if ev.Event != "pv" || ev.Score == nil || len(ev.PV) != len(ev.SAN) {
+				t.Fatalf("%s: got unexpected event %+v", d.FEN, ev)
+			}
+			if ev.MultiPV == 1 {
+				last = &events[i+1]
+			}
+		}
+		if last == nil {
+			t.Errorf("%s: no principal variation", d.FEN)
+		} else if last.ScoreType != "mate" || *last.Score != 1 || last.SAN[0][len(last.SAN[0])-1] != '#' {
+			t.Errorf("%s: got %s %d %v, wanted mate in 1", d.FEN, last.ScoreType, *last.Score, last.SAN)
+		}
+	}
+}
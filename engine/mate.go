@@ -0,0 +1,255 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// mate.go implements a mate solver based on proof-number search.
+//
+// Unlike the alpha-beta search, which finds mates only when they happen
+// to be inside the searched tree, the solver proves that every defence
+// is mated. The tree alternates attacker (OR) nodes, where one mating
+// move is enough, and defender (AND) nodes, where all legal replies
+// must be refuted. The last attacking move must give check so at the
+// frontier the solver searches only checks and evasions.
+//
+// See "Proof-Number Search" by L. V. Allis et al., 1994.
+
+package engine
+
+import (
+	. "bitbucket.org/zurichess/board"
+)
+
+// pnInfinity is the proof or disproof number of a solved node.
+const pnInfinity = 1 << 30
+
+// pnNode is a node in the proof-number search tree.
+type pnNode struct {
+	move     Move   // move leading to this node
+	pn, dn   uint32 // proof and disproof numbers
+	expanded bool   // true if children were generated
+	children []*pnNode
+}
+
+// MateNode is a move in the solution of a mate problem.
+//
+// The Children of an attacking move are all legal replies of the
+// defender, none if the move mates. Each reply has exactly one child,
+// the attacking move that continues the mate.
+type MateNode struct {
+	Move     Move
+	Children []*MateNode
+}
+
+// Moves returns the number of attacking moves to mate,
+// assuming the longest defence, starting with n.
+func (n *MateNode) Moves() int {
+	moves := 1
+	for _, reply := range n.Children {
+		if m := 1 + reply.Children[0].Moves(); m > moves {
+			moves = m
+		}
+	}
+	return moves
+}
+
+// PV returns the main line of the solution starting with n.
+// The defender plays the reply delaying the mate the most.
+func (n *MateNode) PV() []Move {
+	pv := []Move{n.Move}
+	var longest *MateNode
+	for _, reply := range n.Children {
+		if longest == nil || reply.Children[0].Moves() > longest.Children[0].Moves() {
+			longest = reply
+		}
+	}
+	if longest != nil {
+		pv = append(pv, longest.Move)
+		pv = append(pv, longest.Children[0].PV()...)
+	}
+	return pv
+}
+
+// MateSolver searches for forced mates using proof-number search.
+type MateSolver struct {
+	ChecksOnly bool   // if true, all attacking moves must give check
+	MaxNodes   uint64 // maximum number of nodes to search, 0 for no limit
+	Nodes      uint64 // number of nodes searched
+
+	stopped atomicFlag
+}
+
+// SolveMate returns the solution of the shortest mate in at most n
+// moves for the side to move in pos or nil if there is none.
+func SolveMate(pos *Position, n int) *MateNode {
+	return new(MateSolver).Solve(pos, n)
+}
+
+// Stop stops the search. Safe to call from another goroutine.
+func (ms *MateSolver) Stop() {
+	ms.stopped.set()
+}
+
+// Solve returns the solution of the shortest mate in at most n moves
+// for the side to move in pos or nil if there is none or if the search
+// was stopped before the mate was proven. pos is not modified.
+func (ms *MateSolver) Solve(pos *Position, n int) *MateNode {
+	for k := 1; k <= n; k++ {
+		root, solved := ms.prove(pos, k)
+		if !solved {
+			return nil
+		}
+		if root.pn == 0 {
+			return ms.solution(root)
+		}
+	}
+	return nil
+}
+
+// prove runs the proof-number search for a mate in n moves.
+// Returns the root of the search tree and false if the search
+// was stopped before the root was proven or disproven.
+func (ms *MateSolver) prove(pos *Position, n int) (*pnNode, bool) {
+	root := &pnNode{pn: 1, dn: 1}
+	var path []*pnNode
+	for root.pn != 0 && root.dn != 0 {
+		if ms.stopped.get() || ms.MaxNodes != 0 && ms.Nodes >= ms.MaxNodes {
+			return root, false
+		}
+
+		// Select the most proving node.
+		path = append(path[:0], root)
+		for node := root; node.expanded; {
+			node = selectChild(node, len(path)%2 == 1)
+			pos.DoMove(node.move)
+			path = append(path, node)
+		}
+
+		ms.expand(pos, path[len(path)-1], len(path)-1, n)
+
+		// Update the ancestors.
+		for i := len(path) - 2; i >= 0; i-- {
+			pos.UndoMove()
+			path[i].update(i%2 == 0)
+		}
+	}
+	return root, true
+}
+
+// selectChild returns the child of node to be proven first.
+// For attacker nodes that is the easiest move to prove,
+// for defender nodes the easiest move to disprove.
+func selectChild(node *pnNode, attacker bool) *pnNode {
+	best := node.children[0]
+	for _, c := range node.children[1:] {
+		if attacker && c.pn < best.pn || !attacker && c.dn < best.dn {
+			best = c
+		}
+	}
+	return best
+}
+
+// update recomputes the proof and disproof numbers of node from its children.
+func (node *pnNode) update(attacker bool) {
+	min, sum := uint32(pnInfinity), uint32(0)
+	for _, c := range node.children {
+		a, b := c.pn, c.dn
+		if !attacker {
+			a, b = c.dn, c.pn
+		}
+		if a < min {
+			min = a
+		}
+		if sum += b; sum > pnInfinity {
+			sum = pnInfinity
+		}
+	}
+	if attacker {
+		node.pn, node.dn = min, sum
+	} else {
+		node.pn, node.dn = sum, min
+	}
+}
+
+// expand generates the children of node at ply searching for a mate in n moves.
+// The defender nodes are evaluated immediately: mates are proven, stalemates
+// and positions where the attacker has no moves left are disproven.
+func (ms *MateSolver) expand(pos *Position, node *pnNode, ply, n int) {
+	node.expanded = true
+	if ply%2 == 1 {
+		// Defender node: all replies must be refuted.
+		for _, m := range LegalMoves(pos) {
+			ms.Nodes++
+			node.children = append(node.children, &pnNode{move: m, pn: 1, dn: 1})
+		}
+		node.update(false)
+		return
+	}
+
+	// Attacker node: one mating move is enough.
+	them := pos.Them()
+	last := n-ply/2 == 1
+	for _, m := range LegalMoves(pos) {
+		pos.DoMove(m)
+		if check := pos.IsChecked(them); check || !last && !ms.ChecksOnly {
+			ms.Nodes++
+			child := &pnNode{move: m}
+			replies := len(LegalMoves(pos))
+			switch {
+			case replies == 0 && check:
+				child.pn, child.dn = 0, pnInfinity
+			case replies == 0 || last:
+				child.pn, child.dn = pnInfinity, 0
+			default:
+				// Positions with fewer replies are easier to prove.
+				child.pn, child.dn = uint32(replies), 1
+			}
+			node.children = append(node.children, child)
+		}
+		pos.UndoMove()
+	}
+	node.update(true)
+}
+
+// solution returns the solution rooted at the proven attacker node,
+// preferring the shortest mates.
+func (ms *MateSolver) solution(node *pnNode) *MateNode {
+	var best *pnNode
+	bestLen := 0
+	for _, c := range node.children {
+		if c.pn == 0 {
+			if l := mateLength(c); best == nil || l < bestLen {
+				best, bestLen = c, l
+			}
+		}
+	}
+
+	sol := &MateNode{Move: best.move}
+	for _, reply := range best.children {
+		sol.Children = append(sol.Children, &MateNode{
+			Move:     reply.move,
+			Children: []*MateNode{ms.solution(reply)},
+		})
+	}
+	return sol
+}
+
+// mateLength returns the number of attacking moves to mate
+// after the proven defender node, including the move to it.
+func mateLength(node *pnNode) int {
+	length := 1
+	for _, reply := range node.children {
+		shortest := 0
+		for _, c := range reply.children {
+			if c.pn != 0 {
+				continue
+			}
+			if l := mateLength(c); shortest == 0 || l < shortest {
+				shortest = l
+			}
+		}
+		if 1+shortest > length {
+			length = 1 + shortest
+		}
+	}
+	return length
+}
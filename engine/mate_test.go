@@ -0,0 +1,137 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package engine
+
+import (
+	"testing"
+
+	. "bitbucket.org/zurichess/board"
+	. "bitbucket.org/zurichess/zurichess/internal/testdata"
+)
+
+// checkSolution checks that sol mates in at most n moves against all defences.
+func checkSolution(t *testing.T, pos *Position, sol *MateNode, n int) bool {
+	if n == 0 || !IsLegal(pos, sol.Move) {
+		t.Errorf("%v: %v does not mate in time", pos, sol.Move)
+		return false
+	}
+	pos.DoMove(sol.Move)
+	defer pos.UndoMove()
+
+	replies := LegalMoves(pos)
+	if len(replies) == 0 && !pos.IsChecked(pos.Us()) {
+		t.Errorf("%v: stalemate", pos)
+		return false
+	}
+	if len(replies) != len(sol.Children) {
+		t.Errorf("%v: got %d replies, wanted %d", pos, len(sol.Children), len(replies))
+		return false
+	}
+	for i, reply := range sol.Children {
+		if reply.Move != replies[i] || len(reply.Children) != 1 {
+			t.Errorf("%v: reply %v is not refuted", pos, reply.Move)
+			return false
+		}
+		pos.DoMove(reply.Move)
+		ok := checkSolution(t, pos, reply.Children[0], n-1)
+		pos.UndoMove()
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func testSolveMate(t *testing.T, n int, suite []struct{ FEN, BM string }) {
+	for i, d := range suite {
+		pos, _ := PositionFromFEN(d.FEN)
+		sol := SolveMate(pos, n)
+		if sol == nil {
+			t.Errorf("#%d %s: no mate in %d found", i, d.FEN, n)
+			continue
+		}
+		if pos.String() != d.FEN {
+			t.Errorf("#%d %s: position was modified", i, d.FEN)
+		}
+		if bm, _ := pos.UCIToMove(d.BM); sol.Move != bm || sol.Moves() != n {
+			t.Errorf("#%d %s: got mate in %d with %v, wanted mate in %d with %s", i, d.FEN, sol.Moves(), sol.Move, n, d.BM)
+		}
+		if pv := sol.PV(); len(pv) != 2*n-1 {
+			t.Errorf("#%d %s: got pv %v, wanted %d moves", i, d.FEN, pv, 2*n-1)
+		}
+		checkSolution(t, pos, sol, n)
+	}
+}
+
+func TestSolveMateIn1(t *testing.T) {
+	testSolveMate(t, 1, MateIn1)
+}
+
+func TestSolveMateIn2(t *testing.T) {
+	testSolveMate(t, 2, MateIn2)
+}
+
+func TestSolveMateIn3(t *testing.T) {
+	testSolveMate(t, 3, MateIn3)
+}
+
+// Test the alpha-beta search agrees with the mate solver.
+func TestMateIn2Search(t *testing.T) {
+	for i, d := range MateIn2 {
+		pos, _ := PositionFromFEN(d.FEN)
+		tc := NewFixedDepthTimeControl(pos, 4)
+		tc.Start(false)
+		eng := NewEngine(pos, nil, Options{})
+		score, pv := eng.Play(tc)
+		if bm, _ := pos.UCIToMove(d.BM); score != MateScore-3 || len(pv) == 0 || pv[0] != bm {
+			t.Errorf("#%d %s: got score %d and pv %v, wanted mate in 2 with %s", i, d.FEN, score, pv, d.BM)
+		}
+	}
+}
+
+func TestSolveMateShortest(t *testing.T) {
+	// Mate in 1 is found even if a longer mate is requested.
+	for _, d := range MateIn1[:8] {
+		pos, _ := PositionFromFEN(d.FEN)
+		bm, _ := pos.UCIToMove(d.BM)
+		if sol := SolveMate(pos, 3); sol == nil || sol.Moves() != 1 || sol.Move != bm {
+			t.Errorf("%s: expected mate in 1 with %s", d.FEN, d.BM)
+		}
+	}
+}
+
+func TestSolveMateNoMate(t *testing.T) {
+	for _, fen := range []string{
+		FENStartPos,
+		"8/8/8/8/8/8/8/K1k5 w - - 0 1",   // only kings
+		"7k/5Q2/6K1/8/8/8/8/8 b - - 0 1", // stalemate
+	} {
+		pos, _ := PositionFromFEN(fen)
+		if sol := SolveMate(pos, 2); sol != nil {
+			t.Errorf("%s: got mate with %v, wanted none", fen, sol.PV())
+		}
+	}
+}
+
+func TestMateSolverLimits(t *testing.T) {
+	pos, _ := PositionFromFEN(MateIn3[1].FEN)
+	ms := &MateSolver{MaxNodes: 100}
+	if sol := ms.Solve(pos, 3); sol != nil || ms.Nodes < 100 {
+		t.Errorf("expected the search to stop after 100 nodes, got %d nodes", ms.Nodes)
+	}
+
+	ms = &MateSolver{}
+	ms.Stop()
+	if sol := ms.Solve(pos, 3); sol != nil || ms.Nodes != 0 {
+		t.Errorf("expected the search to stop immediately, got %d nodes", ms.Nodes)
+	}
+
+	// Restricted to checks, a mate starting with a quiet move is missed.
+	pos, _ = PositionFromFEN(MateIn3[5].FEN)
+	ms = &MateSolver{ChecksOnly: true}
+	if sol := ms.Solve(pos, 3); sol != nil {
+		t.Errorf("got mate with %v, wanted none", sol.PV())
+	}
+}
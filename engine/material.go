@@ -37,6 +37,7 @@ const (
 var (
 	// Evaluation caches.
 	pawnsAndShelterCache pawnsTable
+	materialCache        materialTable
 
 	// Figure bonuses to use when computing the futility margin.
 	futilityFigureBonus [FigureArraySize]int32
@@ -51,6 +52,10 @@ type Eval struct {
 	Accum [ColorArraySize]Accum
 	// Position evaluated.
 	position *Position
+	// Scale of the score for known endgames, scaleNormal otherwise.
+	scale int32
+	// Bonus in centipawns from White's POV for known won endgames.
+	bonus int32
 }
 
 // GetCentipawnsScore returns the current position evalution
@@ -58,22 +63,50 @@ type Eval struct {
 func (e Eval) GetCentipawnsScore() int32 {
 	phase := Phase(e.position)
 	score := (e.Accum[NoColor].M*(256-phase) + e.Accum[NoColor].E*phase) / 256
-	return scaleToCentipawns(score)
+	score = score * e.scale / scaleNormal
+	return scaleToCentipawns(score) + e.bonus
 }
 
 // Evaluate evaluates the position pos.
+//
+// Evaluate uses a global cache so it is not safe for concurrent use.
+// Concurrent engines should use Engine.Score instead.
 func Evaluate(pos *Position) Eval {
+	return evaluatePosition(pos, &pawnsAndShelterCache, &materialCache)
+}
+
+// evaluatePosition evaluates the position pos using pawns to cache
+// the pawns and king shelter evaluation and materials to cache
+// the material evaluation.
+func evaluatePosition(pos *Position, pawns *pawnsTable, materials *materialTable) Eval {
 	e := Eval{position: pos}
 
 	e.Accum[White] = evaluate(pos, White)
 	e.Accum[Black] = evaluate(pos, Black)
 
-	wps, bps := pawnsAndShelterCache.load(pos)
+	wps, bps := pawns.load(pos)
 	e.Accum[White].merge(wps)
 	e.Accum[Black].merge(bps)
 
+	wms, bms, scale := materials.load(pos)
+	e.Accum[White].merge(wms)
+	e.Accum[Black].merge(bms)
+	e.scale = scale
+
+	att := [ColorArraySize]attacks{
+		White: computeAttacks(pos, White),
+		Black: computeAttacks(pos, Black),
+	}
+	evaluateKingSafety(pos, White, &att, &e.Accum[White])
+	evaluateKingSafety(pos, Black, &att, &e.Accum[Black])
+	evaluateThreats(pos, White, &att, &e.Accum[White])
+	evaluateThreats(pos, Black, &att, &e.Accum[Black])
+	evaluateSpace(pos, White, &att, &e.Accum[White])
+	evaluateSpace(pos, Black, &att, &e.Accum[Black])
+
 	e.Accum[NoColor].merge(e.Accum[White])
 	e.Accum[NoColor].deduct(e.Accum[Black])
+	evaluateEndgame(pos, &e)
 	return e
 }
 
@@ -98,8 +131,12 @@ func evaluateShelter(pos *Position, us Color, accum *Accum) {
 	bb := Kings(pos, us)
 	kingSq := bb.AsSquare()
 	mobility := KingMobility(kingSq)
-	groupByFileSq(fKingFile, us, kingSq, accum)
-	groupByRankSq(fKingRank, us, kingSq, accum)
+	if fullPSQT {
+		groupBySquare(fKingSquare, us, bb, accum)
+	} else {
+		groupByFileSq(fKingFile, us, kingSq, accum)
+		groupByRankSq(fKingRank, us, kingSq, accum)
+	}
 	groupByBoard(fKingAttack, mobility, accum)
 
 	// King's shelter.
@@ -134,6 +171,7 @@ func evaluate(pos *Position, us Color) Accum {
 	ourPawns := Pawns(pos, us)
 	theirPawns := pos.ByPiece(them, Pawn)
 	theirKingArea := KingArea(pos, them)
+	bucket := kingBucket(pos, us)
 
 	groupByBoard(fNoFigure, BbEmpty, &accum)
 	groupByBoard(fPawn, Pawns(pos, us), &accum)
@@ -159,8 +197,7 @@ func evaluate(pos *Position, us Color) Accum {
 		sq := bb.Pop()
 		mobility := KnightMobility(sq) &^ (danger | ourPawns)
 		attacks |= mobility
-		groupByFileSq(fKnightFile, us, sq, &accum)
-		groupByRankSq(fKnightRank, us, sq, &accum)
+		groupByFigureSq(fKnightFile, fKnightRank, fKnightSquare, us, sq, bucket, &accum)
 		groupByBoard(fKnightAttack, mobility, &accum)
 		if mobility&theirKingArea&^theirPawns != 0 {
 			numAttackers++
@@ -175,8 +212,7 @@ func evaluate(pos *Position, us Color) Accum {
 		attacks |= mobility
 		mobility &^= danger | ourPawns
 		numBishops++
-		groupByFileSq(fBishopFile, us, sq, &accum)
-		groupByRankSq(fBishopRank, us, sq, &accum)
+		groupByFigureSq(fBishopFile, fBishopRank, fBishopSquare, us, sq, bucket, &accum)
 		groupByBoard(fBishopAttack, mobility, &accum)
 		if mobility&theirKingArea&^theirPawns != 0 {
 			numAttackers++
@@ -189,8 +225,7 @@ func evaluate(pos *Position, us Color) Accum {
 		sq := bb.Pop()
 		mobility := RookMobility(sq, all) &^ (danger | ourPawns)
 		attacks |= mobility
-		groupByFileSq(fRookFile, us, sq, &accum)
-		groupByRankSq(fRookRank, us, sq, &accum)
+		groupByFigureSq(fRookFile, fRookRank, fRookSquare, us, sq, bucket, &accum)
 		groupByBoard(fRookAttack, mobility, &accum)
 		groupByBool(fRookOnOpenFile, openFiles.Has(sq), &accum)
 		groupByBool(fRookOnSemiOpenFile, semiOpenFiles.Has(sq), &accum)
@@ -203,8 +238,7 @@ func evaluate(pos *Position, us Color) Accum {
 		sq := bb.Pop()
 		mobility := QueenMobility(sq, all) &^ (danger | ourPawns)
 		attacks |= mobility
-		groupByFileSq(fQueenFile, us, sq, &accum)
-		groupByRankSq(fQueenRank, us, sq, &accum)
+		groupByFigureSq(fQueenFile, fQueenRank, fQueenSquare, us, sq, bucket, &accum)
 		groupByBoard(fQueenAttack, mobility, &accum)
 		if mobility&theirKingArea&^theirPawns != 0 {
 			numAttackers++
@@ -217,14 +251,119 @@ func evaluate(pos *Position, us Color) Accum {
 	groupByBoard(fAttackedMinors, attacks&Minors(pos, them), &accum)
 	groupByBool(fBishopPair, numBishops == 2, &accum)
 
-	// Kink's safety is very primitive:
+	// King's safety:
 	// - king's shelter is evaluated by evaluateShelter.
+	// - attacks and checks are evaluated by evaluateKingSafety.
 	// - the following counts the number of attackers.
-	// TODO: Queen tropism which was dropped during the last refactoring.
 	groupByBucket(fKingAttackers, numAttackers, 4, &accum)
 	return accum
 }
 
+// attacks stores the squares attacked by the pieces of one side.
+type attacks struct {
+	byFigure [FigureArraySize]Bitboard // squares attacked by each figure
+	all      Bitboard                  // squares attacked by any piece
+}
+
+// computeAttacks returns the squares attacked by the pieces of us.
+func computeAttacks(pos *Position, us Color) attacks {
+	var a attacks
+	all := pos.ByColor(White) | pos.ByColor(Black)
+	a.byFigure[Pawn] = PawnThreats(pos, us)
+	for bb := Knights(pos, us); bb != BbEmpty; {
+		a.byFigure[Knight] |= KnightMobility(bb.Pop())
+	}
+	for bb := Bishops(pos, us); bb != BbEmpty; {
+		a.byFigure[Bishop] |= BishopMobility(bb.Pop(), all)
+	}
+	for bb := Rooks(pos, us); bb != BbEmpty; {
+		a.byFigure[Rook] |= RookMobility(bb.Pop(), all)
+	}
+	for bb := Queens(pos, us); bb != BbEmpty; {
+		a.byFigure[Queen] |= QueenMobility(bb.Pop(), all)
+	}
+	a.byFigure[King] = KingMobility(Kings(pos, us).AsSquare())
+	for fig := Pawn; fig <= King; fig++ {
+		a.all |= a.byFigure[fig]
+	}
+	return a
+}
+
+// evaluateKingSafety evaluates the attacks of us on their king.
+func evaluateKingSafety(pos *Position, us Color, att *[ColorArraySize]attacks, accum *Accum) {
+	them := us.Opposite()
+	all := pos.ByColor(White) | pos.ByColor(Black)
+	king := Kings(pos, them)
+	kingSq := king.AsSquare()
+	kingArea := KingArea(pos, them)
+
+	// Attack units of each figure on the squares around their king.
+	for fig := Knight; fig <= Queen; fig++ {
+		groupByFigure(fKingAttackUnits, fig, (att[us].byFigure[fig] & kingArea).Count(), accum)
+	}
+
+	// Checks from squares that are not attacked by them.
+	var checks [FigureArraySize]Bitboard
+	checks[Knight] = KnightMobility(kingSq)
+	checks[Bishop] = BishopMobility(kingSq, all)
+	checks[Rook] = RookMobility(kingSq, all)
+	checks[Queen] = checks[Bishop] | checks[Rook]
+	safe := ^pos.ByColor(us) &^ att[them].all
+	for fig := Knight; fig <= Queen; fig++ {
+		groupByFigure(fSafeChecks, fig, (checks[fig] & att[us].byFigure[fig] & safe).Count(), accum)
+	}
+
+	// Open files and files without their pawns around their king.
+	files := Fill(East(king)|king|West(king)) & BbRank1
+	groupByBoard(fKingZoneOpenFiles, OpenFiles(pos, us)&files, accum)
+	groupByBoard(fKingZoneSemiOpenFiles, SemiOpenFiles(pos, them)&files, accum)
+}
+
+// evaluateThreats evaluates the threats of us on their pieces
+// and our pieces that are not defended.
+func evaluateThreats(pos *Position, us Color, att *[ColorArraySize]attacks, accum *Accum) {
+	them := us.Opposite()
+	minors := att[us].byFigure[Knight] | att[us].byFigure[Bishop]
+	groupByBoard(fHangingPieces, MinorsAndMajors(pos, them)&att[us].all&^att[them].all, accum)
+	groupByBoard(fLoosePieces, MinorsAndMajors(pos, us)&^att[us].all, accum)
+	groupByBoard(fMinorsAttackMajors, minors&Majors(pos, them), accum)
+	groupByBoard(fRooksAttackQueens, att[us].byFigure[Rook]&Queens(pos, them), accum)
+}
+
+// evaluateSpace evaluates the outposts and the space of us.
+func evaluateSpace(pos *Position, us Color, att *[ColorArraySize]attacks, accum *Accum) {
+	them := us.Opposite()
+	ourPawns := Pawns(pos, us)
+	theirPawns := pos.ByPiece(them, Pawn)
+
+	// Outposts are squares on ranks 4 to 6 defended by our pawns
+	// that cannot be attacked by their pawns.
+	outposts, area := BbRank4|BbRank5|BbRank6, BbRank2|BbRank3|BbRank4
+	if us == Black {
+		outposts, area = BbRank5|BbRank4|BbRank3, BbRank7|BbRank6|BbRank5
+	}
+	outposts &= att[us].byFigure[Pawn] &^ ForwardSpan(them, East(theirPawns)|West(theirPawns))
+	groupByBoard(fKnightOutposts, Knights(pos, us)&outposts, accum)
+	groupByBoard(fBishopOutposts, Bishops(pos, us)&outposts, accum)
+
+	// Space is the number of safe squares in the center of our half
+	// of the board. The squares behind our pawns count twice.
+	space := area & (BbFileC | BbFileD | BbFileE | BbFileF) &^ ourPawns &^ att[them].byFigure[Pawn]
+	groupByCount(fSpace, space.Count()+(space&BackwardSpan(us, ourPawns)).Count(), accum)
+}
+
+// kingBuckets is the number of piece square tables for each figure.
+const kingBuckets = 2
+
+// kingBucket returns the piece square table used for the figures of us:
+// 0 if our king is on the queen side, 1 if it is on the king side.
+func kingBucket(pos *Position, us Color) int {
+	if Kings(pos, us).AsSquare().File() < 4 {
+		return 0
+	}
+	return 1
+}
+
 // Phase computes the progress of the game.
 // 0 is opening, 256 is late end game.
 func Phase(pos *Position) int32 {
@@ -5,12 +5,158 @@
 package engine
 
 import (
+	"math/rand"
+	"reflect"
+	"strings"
 	"testing"
 
 	. "bitbucket.org/zurichess/board"
 	. "bitbucket.org/zurichess/zurichess/internal/testdata"
 )
 
+// flipFEN returns fen with the board mirrored vertically and the colors swapped.
+func flipFEN(fen string) string {
+	swapCase := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if 'a' <= r && r <= 'z' {
+				return r - 'a' + 'A'
+			}
+			if 'A' <= r && r <= 'Z' {
+				return r - 'A' + 'a'
+			}
+			return r
+		}, s)
+	}
+
+	f := strings.Fields(fen)
+	ranks := strings.Split(f[0], "/")
+	for i, j := 0, len(ranks)-1; i < j; i, j = i+1, j-1 {
+		ranks[i], ranks[j] = ranks[j], ranks[i]
+	}
+	f[0] = swapCase(strings.Join(ranks, "/"))
+	if f[1] == "w" {
+		f[1] = "b"
+	} else {
+		f[1] = "w"
+	}
+	if f[2] != "-" {
+		castle, swapped := "", swapCase(f[2])
+		for _, c := range "KQkq" {
+			if strings.ContainsRune(swapped, c) {
+				castle += string(c)
+			}
+		}
+		f[2] = castle
+	}
+	if f[3] != "-" {
+		f[3] = f[3][:1] + string('1'+'8'-f[3][1])
+	}
+	return strings.Join(f, " ")
+}
+
+// randomPositions returns the positions of n games with random moves.
+func randomPositions(n int) []string {
+	r := rand.New(rand.NewSource(1))
+	var fens []string
+	for i := 0; i < n; i++ {
+		pos, _ := PositionFromFEN(FENStartPos)
+		for ply := 0; ply < 200; ply++ {
+			moves := LegalMoves(pos)
+			if len(moves) == 0 {
+				break
+			}
+			pos.DoMove(moves[r.Intn(len(moves))])
+			fens = append(fens, pos.String())
+		}
+	}
+	return fens
+}
+
+// testPositions returns all positions in the test suites and some random positions.
+func testPositions() []string {
+	var fens []string
+	fens = append(fens, TestFENs...)
+	for _, game := range TestGames {
+		pos, _ := PositionFromFEN(FENStartPos)
+		for _, move := range strings.Fields(game) {
+			m, _ := pos.UCIToMove(move)
+			pos.DoMove(m)
+			fens = append(fens, pos.String())
+		}
+	}
+	return append(fens, randomPositions(50)...)
+}
+
+// checkSymmetry checks that fen and its color flipped position have the same evaluation.
+func checkSymmetry(t *testing.T, fen string) {
+	flipped := flipFEN(fen)
+	pos, err := PositionFromFEN(fen)
+	if err != nil {
+		t.Fatalf("%s: %v", fen, err)
+	}
+	other, err := PositionFromFEN(flipped)
+	if err != nil {
+		t.Fatalf("%s: %v", flipped, err)
+	}
+
+	var pawns pawnsTable
+	var materials materialTable
+	e1 := evaluatePosition(pos, &pawns, &materials)
+	e2 := evaluatePosition(other, &pawns, &materials)
+	if !reflect.DeepEqual(e1.Accum[White], e2.Accum[Black]) || !reflect.DeepEqual(e1.Accum[Black], e2.Accum[White]) {
+		t.Errorf("%s: got accums %v and %v for the flipped position, wanted swapped", fen, e1.Accum, e2.Accum)
+	}
+	if s1, s2 := e1.GetCentipawnsScore(), e2.GetCentipawnsScore(); s1 != -s2 {
+		t.Errorf("%s: got score %d, and %d for the flipped position %s", fen, s1, s2, flipped)
+	}
+}
+
+// checkPawnsCache checks that pawns returns the same evaluation for pos as a fresh evaluation.
+func checkPawnsCache(t *testing.T, pawns *pawnsTable, pos *Position) {
+	white, black := pawns.load(pos)
+	if want := evaluatePawnsAndShelter(pos, White); !reflect.DeepEqual(white, want) {
+		t.Errorf("%v: got %v for White from the pawns cache, wanted %v", pos, white, want)
+	}
+	if want := evaluatePawnsAndShelter(pos, Black); !reflect.DeepEqual(black, want) {
+		t.Errorf("%v: got %v for Black from the pawns cache, wanted %v", pos, black, want)
+	}
+}
+
+// positionToBytes encodes the side to move and the pieces of pos.
+func positionToBytes(pos *Position) []byte {
+	data := []byte{byte(pos.Us())}
+	for sq := SquareMinValue; sq <= SquareMaxValue; sq++ {
+		if pi := pos.Get(sq); pi != NoPiece {
+			data = append(data, byte(sq), byte(pi-PieceMinValue))
+		}
+	}
+	return data
+}
+
+// positionFromBytes decodes a position encoded by positionToBytes.
+// Any data is accepted, but positions without exactly one king
+// for each side or with pawns on the first or last rank are rejected.
+func positionFromBytes(data []byte) *Position {
+	if len(data) == 0 {
+		return nil
+	}
+	pos := NewPosition()
+	pos.SetSideToMove(ColorMinValue + Color(data[0]%2))
+	for i := 1; i+1 < len(data); i += 2 {
+		sq := Square(data[i] % 64)
+		pi := PieceMinValue + Piece(data[i+1]%12)
+		if pos.Get(sq) == NoPiece {
+			pos.Put(sq, pi)
+		}
+	}
+	if pos.ByPiece(White, King).Count() != 1 ||
+		pos.ByPiece(Black, King).Count() != 1 ||
+		pos.ByFigure(Pawn)&(BbRank1|BbRank8) != 0 {
+		return nil
+	}
+	return pos
+}
+
 func TestScoreRange(t *testing.T) {
 	for _, fen := range TestFENs {
 		pos, _ := PositionFromFEN(fen)
@@ -22,6 +168,49 @@ func TestScoreRange(t *testing.T) {
 	}
 }
 
+func TestKingBucket(t *testing.T) {
+	pos, _ := PositionFromFEN("2kr4/8/8/8/8/8/8/4K3 w - - 0 1")
+	if b := kingBucket(pos, White); b != 1 {
+		t.Errorf("got bucket %d for White, wanted 1", b)
+	}
+	if b := kingBucket(pos, Black); b != 0 {
+		t.Errorf("got bucket %d for Black, wanted 0", b)
+	}
+}
+
+func TestFlipFEN(t *testing.T) {
+	data := []struct{ fen, flipped string }{
+		{FENStartPos, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR b KQkq - 0 1"},
+		{"r3k2r/8/8/3pP3/8/8/8/4K2R w Kq d6 0 3", "4k2r/8/8/8/3Pp3/8/8/R3K2R b Qk d3 0 3"},
+	}
+	for _, d := range data {
+		if got := flipFEN(d.fen); got != d.flipped {
+			t.Errorf("got flipFEN(%s) == %s, wanted %s", d.fen, got, d.flipped)
+		}
+		if got := flipFEN(d.flipped); got != d.fen {
+			t.Errorf("got flipFEN(%s) == %s, wanted %s", d.flipped, got, d.fen)
+		}
+	}
+}
+
+// The evaluation is symmetric only between colors. Mirroring the board
+// left to right changes the score because each file has its own weights.
+func TestEvaluateSymmetry(t *testing.T) {
+	for _, fen := range testPositions() {
+		checkSymmetry(t, fen)
+	}
+}
+
+func TestPawnsCacheConsistency(t *testing.T) {
+	// The cache is shared by all positions like during a search.
+	pawns := new(pawnsTable)
+	for _, fen := range testPositions() {
+		pos, _ := PositionFromFEN(fen)
+		checkPawnsCache(t, pawns, pos)
+		checkPawnsCache(t, pawns, pos)
+	}
+}
+
 func BenchmarkScore(b *testing.B) {
 	for _, fen := range TestFENs {
 		pos, _ := PositionFromFEN(fen)
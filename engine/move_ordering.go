@@ -64,6 +64,13 @@ func (st *stack) Reset(pos *Position) {
 	st.moves = st.moves[:0]
 }
 
+// clearCounters removes all counter moves.
+func (st *stack) clearCounters() {
+	for i := range st.counter {
+		st.counter[i] = NullMove
+	}
+}
+
 // get returns the moveStack for current ply.
 // allocates memory if necessary.
 func (st *stack) get() *moveStack {
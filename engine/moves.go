@@ -0,0 +1,60 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package engine
+
+import (
+	"fmt"
+
+	. "bitbucket.org/zurichess/board"
+)
+
+// LegalMoves returns the legal moves in pos.
+func LegalMoves(pos *Position) []Move {
+	var moves []Move
+	pos.GenerateMoves(Violent|Quiet, &moves)
+	us, n := pos.Us(), 0
+	for _, m := range moves {
+		pos.DoMove(m)
+		if !pos.IsChecked(us) {
+			moves[n] = m
+			n++
+		}
+		pos.UndoMove()
+	}
+	return moves[:n]
+}
+
+// IsLegal returns true if m is a legal move in pos.
+func IsLegal(pos *Position, m Move) bool {
+	for _, legal := range LegalMoves(pos) {
+		if legal == m {
+			return true
+		}
+	}
+	return false
+}
+
+// PositionFromUCI returns the position after moves in UCI format
+// are played from fen. If fen is empty the start position is used.
+func PositionFromUCI(fen string, moves []string) (*Position, error) {
+	if fen == "" {
+		fen = FENStartPos
+	}
+	pos, err := PositionFromFEN(fen)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range moves {
+		m, err := pos.UCIToMove(s)
+		if err != nil {
+			return nil, err
+		}
+		if !IsLegal(pos, m) {
+			return nil, fmt.Errorf("%s is not a legal move", s)
+		}
+		pos.DoMove(m)
+	}
+	return pos, nil
+}
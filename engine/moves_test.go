@@ -0,0 +1,42 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package engine
+
+import (
+	"testing"
+
+	. "bitbucket.org/zurichess/board"
+)
+
+func TestLegalMoves(t *testing.T) {
+	pos, _ := PositionFromFEN(FENStartPos)
+	if n := len(LegalMoves(pos)); n != 20 {
+		t.Errorf("got %d legal moves in the start position, wanted 20", n)
+	}
+
+	// The pinned knight cannot move.
+	pos, _ = PositionFromFEN("4k3/8/8/8/8/8/4N3/r3K2r w - - 0 1")
+	for _, m := range LegalMoves(pos) {
+		if m.Piece().Figure() != King {
+			t.Errorf("got illegal move %v", m)
+		}
+	}
+}
+
+func TestPositionFromUCI(t *testing.T) {
+	pos, err := PositionFromUCI("", []string{"e2e4", "e7e5", "g1f3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "rnbqkbnr/pppp1ppp/8/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R b KQkq - 1 2"; pos.String() != want {
+		t.Errorf("got %s, wanted %s", pos, want)
+	}
+
+	for _, moves := range [][]string{{"e2e5"}, {"e1e2"}, {"e2e4", "e2e4"}, {"x"}} {
+		if _, err := PositionFromUCI("", moves); err == nil {
+			t.Errorf("%v: expected error", moves)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// nnue.go implements an efficiently updatable neural network evaluation.
+//
+// The network has 768 inputs, one for each piece (relative to the
+// perspective) on each square, a hidden layer of N neurons computed
+// separately for each side's perspective, and one output:
+//
+//   y = W_2 * [crelu(W_1 * x_us + b_1), crelu(W_1 * x_them + b_1)] + b_2
+//
+// where x_us and x_them are the inputs seen by the side to move and by its
+// opponent, and crelu is the ReLU clipped to [0, nnueQuantA].
+//
+// A move changes only a few inputs, so the hidden layer before activation,
+// the accumulator, is updated incrementally by Engine.DoMove and restored
+// by Engine.UndoMove. The weights are quantized to int16.
+//
+// The network file is little endian:
+//
+//   magic   [4]byte  "ZNN1"
+//   hidden  uint32   N
+//   w1      [768*N]int16, input major
+//   b1      [N]int16
+//   w2      [2*N]int16, side to move first
+//   b2      int32
+
+package engine
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	. "bitbucket.org/zurichess/board"
+)
+
+const (
+	nnueInputs  = 2 * 6 * SquareArraySize // (us, them) x (pawn ... king) x square
+	nnueQuantA  = 255                     // activation scale
+	nnueQuantB  = 64                      // output weights scale
+	nnueScale   = 400                     // converts the output to centipawns
+	nnueMagic   = "ZNN1"
+	nnueMaxSize = 4096 // maximum number of hidden neurons
+)
+
+// Network is a quantized neural network with one hidden layer.
+type Network struct {
+	Hidden int     // number of hidden neurons, for each perspective
+	W1     []int16 // input weights, nnueInputs x Hidden
+	B1     []int16 // hidden biases
+	W2     []int16 // output weights, 2 x Hidden
+	B2     int32   // output bias
+}
+
+// NewNetwork returns a network with hidden neurons and all weights zero.
+func NewNetwork(hidden int) *Network {
+	return &Network{
+		Hidden: hidden,
+		W1:     make([]int16, nnueInputs*hidden),
+		B1:     make([]int16, hidden),
+		W2:     make([]int16, 2*hidden),
+	}
+}
+
+// ReadNetwork reads a network from r.
+func ReadNetwork(r io.Reader) (*Network, error) {
+	var header struct {
+		Magic  [4]byte
+		Hidden uint32
+	}
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return nil, fmt.Errorf("nnue: %v", err)
+	}
+	if string(header.Magic[:]) != nnueMagic {
+		return nil, errors.New("nnue: not a network file")
+	}
+	if header.Hidden == 0 || header.Hidden > nnueMaxSize {
+		return nil, fmt.Errorf("nnue: invalid hidden layer size %d", header.Hidden)
+	}
+
+	n := NewNetwork(int(header.Hidden))
+	for _, data := range []interface{}{n.W1, n.B1, n.W2, &n.B2} {
+		if err := binary.Read(r, binary.LittleEndian, data); err != nil {
+			return nil, fmt.Errorf("nnue: %v", err)
+		}
+	}
+	return n, nil
+}
+
+// ReadNetworkFile reads a network from the file name.
+func ReadNetworkFile(name string) (*Network, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadNetwork(bufio.NewReader(f))
+}
+
+// Write writes n to w in the network file format.
+func (n *Network) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(nnueMagic)
+	for _, data := range []interface{}{uint32(n.Hidden), n.W1, n.B1, n.W2, n.B2} {
+		if err := binary.Write(bw, binary.LittleEndian, data); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// nnueFeature returns the input of piece pi on sq seen by perspective.
+func nnueFeature(perspective Color, pi Piece, sq Square) int {
+	rel := 0
+	if pi.Color() != perspective {
+		rel = 1
+	}
+	return (rel*6+int(pi.Figure()-Pawn))*SquareArraySize + int(sq.POV(perspective))
+}
+
+// accumulator is the hidden layer before activation of a position.
+type accumulator struct {
+	key uint64                  // Zobrist key of the position
+	v   [ColorArraySize][]int16 // for each perspective
+}
+
+// nnueState keeps the accumulators of the positions on the search stack.
+type nnueState struct {
+	net   *Network
+	stack []accumulator
+}
+
+// reset clears the stack and starts using net.
+func (ns *nnueState) reset(net *Network) {
+	ns.net = net
+	ns.stack = ns.stack[:0]
+}
+
+// push pushes a new accumulator and returns it.
+func (ns *nnueState) push() *accumulator {
+	if len(ns.stack) == cap(ns.stack) {
+		ns.stack = append(ns.stack, accumulator{})
+	} else {
+		ns.stack = ns.stack[:len(ns.stack)+1]
+	}
+	acc := &ns.stack[len(ns.stack)-1]
+	for _, col := range []Color{White, Black} {
+		if len(acc.v[col]) != ns.net.Hidden {
+			acc.v[col] = make([]int16, ns.net.Hidden)
+		}
+	}
+	return acc
+}
+
+// pop removes the last accumulator.
+func (ns *nnueState) pop() {
+	if len(ns.stack) != 0 {
+		ns.stack = ns.stack[:len(ns.stack)-1]
+	}
+}
+
+// top returns the last accumulator or nil if the stack is empty.
+func (ns *nnueState) top() *accumulator {
+	if len(ns.stack) == 0 {
+		return nil
+	}
+	return &ns.stack[len(ns.stack)-1]
+}
+
+// current returns the accumulator of pos, refreshing it
+// if pos was changed without Engine.DoMove.
+func (ns *nnueState) current(pos *Position) *accumulator {
+	acc := ns.top()
+	if acc == nil || acc.key != pos.Zobrist() {
+		ns.pop()
+		acc = ns.push()
+		ns.refresh(acc, pos)
+	}
+	return acc
+}
+
+// refresh computes acc from scratch.
+func (ns *nnueState) refresh(acc *accumulator, pos *Position) {
+	acc.key = pos.Zobrist()
+	for _, col := range []Color{White, Black} {
+		copy(acc.v[col], ns.net.B1)
+	}
+	for bb := pos.ByColor(White) | pos.ByColor(Black); bb != 0; {
+		sq := bb.Pop()
+		pi := pos.Get(sq)
+		for _, col := range []Color{White, Black} {
+			ns.add(acc.v[col], nnueFeature(col, pi, sq))
+		}
+	}
+}
+
+// doMove pushes the accumulator of pos after move was played from the
+// position with Zobrist key prev. The accumulator is updated incrementally
+// if the accumulator of the previous position is available.
+func (ns *nnueState) doMove(prev uint64, move Move, pos *Position) {
+	top := ns.top()
+	incremental := top != nil && top.key == prev
+	acc := ns.push()
+	if !incremental {
+		ns.refresh(acc, pos)
+		return
+	}
+	parent := &ns.stack[len(ns.stack)-2] // push may have moved the stack
+
+	acc.key = pos.Zobrist()
+	for _, col := range []Color{White, Black} {
+		v := acc.v[col]
+		copy(v, parent.v[col])
+		if move == NullMove {
+			continue
+		}
+		ns.sub(v, nnueFeature(col, move.Piece(), move.From()))
+		ns.add(v, nnueFeature(col, move.Target(), move.To()))
+		if move.Capture() != NoPiece {
+			ns.sub(v, nnueFeature(col, move.Capture(), move.CaptureSquare()))
+		}
+		if move.MoveType() == Castling {
+			rook, start, end := CastlingRook(move.To())
+			ns.sub(v, nnueFeature(col, rook, start))
+			ns.add(v, nnueFeature(col, rook, end))
+		}
+	}
+}
+
+func (ns *nnueState) add(v []int16, feature int) {
+	w := ns.net.W1[feature*ns.net.Hidden : (feature+1)*ns.net.Hidden]
+	for i := range v {
+		v[i] += w[i]
+	}
+}
+
+func (ns *nnueState) sub(v []int16, feature int) {
+	w := ns.net.W1[feature*ns.net.Hidden : (feature+1)*ns.net.Hidden]
+	for i := range v {
+		v[i] -= w[i]
+	}
+}
+
+// evaluate returns the score of acc in centipawns from us' point of view.
+func (ns *nnueState) evaluate(acc *accumulator, us Color) int32 {
+	n := ns.net
+	sum := int64(0)
+	for i, x := range acc.v[us] {
+		sum += int64(n.W2[i]) * int64(crelu(x))
+	}
+	for i, x := range acc.v[us.Opposite()] {
+		sum += int64(n.W2[n.Hidden+i]) * int64(crelu(x))
+	}
+	sum = (sum + int64(n.B2)) * nnueScale / (nnueQuantA * nnueQuantB)
+	if sum >= KnownWinScore {
+		return KnownWinScore - 1
+	}
+	if sum <= KnownLossScore {
+		return KnownLossScore + 1
+	}
+	return int32(sum)
+}
+
+// crelu is the clipped ReLU activation.
+func crelu(x int16) int16 {
+	if x < 0 {
+		return 0
+	}
+	if x > nnueQuantA {
+		return nnueQuantA
+	}
+	return x
+}
@@ -0,0 +1,172 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package engine
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "bitbucket.org/zurichess/board"
+	. "bitbucket.org/zurichess/zurichess/internal/testdata"
+)
+
+// randomNetwork returns a network with small random weights.
+func randomNetwork(hidden int) *Network {
+	r := rand.New(rand.NewSource(1))
+	n := NewNetwork(hidden)
+	for i := range n.W1 {
+		n.W1[i] = int16(r.Intn(65) - 32)
+	}
+	for i := range n.B1 {
+		n.B1[i] = int16(r.Intn(129))
+	}
+	for i := range n.W2 {
+		n.W2[i] = int16(r.Intn(129) - 64)
+	}
+	n.B2 = int32(r.Intn(2001) - 1000)
+	return n
+}
+
+// checkAccumulator checks that the incremental accumulator of eng
+// is the same as the accumulator computed from scratch.
+func checkAccumulator(t *testing.T, eng *Engine) bool {
+	got := eng.nnue.top()
+	if got == nil || got.key != eng.Position.Zobrist() {
+		t.Errorf("%v: accumulator is not up to date", eng.Position)
+		return false
+	}
+	ns := &nnueState{net: eng.Network}
+	want := ns.push()
+	ns.refresh(want, eng.Position)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%v: incremental accumulator differs from refreshed accumulator", eng.Position)
+		return false
+	}
+	return true
+}
+
+func TestNNUEIncremental(t *testing.T) {
+	for _, game := range TestGames {
+		pos, _ := PositionFromFEN(FENStartPos)
+		eng := NewEngine(pos, nil, Options{})
+		eng.Network = randomNetwork(16)
+		eng.Score()
+
+		for _, move := range strings.Fields(game) {
+			m, _ := eng.Position.UCIToMove(move)
+			eng.DoMove(m)
+			if !checkAccumulator(t, eng) {
+				break
+			}
+		}
+	}
+}
+
+func TestNNUEAllMoves(t *testing.T) {
+	// Kiwipete has castling, en passant and promotions a few plies deep.
+	pos, _ := PositionFromFEN(FENKiwipete)
+	eng := NewEngine(pos, nil, Options{})
+	eng.Network = randomNetwork(16)
+	score := eng.Score()
+
+	var walk func(depth int)
+	walk = func(depth int) {
+		before := *eng.nnue.top()
+		before.v[White] = append([]int16(nil), before.v[White]...)
+		before.v[Black] = append([]int16(nil), before.v[Black]...)
+
+		moves := append(LegalMoves(eng.Position), NullMove)
+		for _, m := range moves {
+			eng.DoMove(m)
+			ok := checkAccumulator(t, eng)
+			if ok && depth > 1 {
+				walk(depth - 1)
+			}
+			eng.UndoMove()
+			if !ok {
+				return
+			}
+			if !reflect.DeepEqual(eng.nnue.top(), &before) {
+				t.Fatalf("%v: accumulator was not restored after %v", eng.Position, m)
+			}
+		}
+	}
+	walk(3)
+
+	if got := eng.Score(); got != score {
+		t.Errorf("got score %d after walking the tree, wanted %d", got, score)
+	}
+}
+
+func TestNNUEScore(t *testing.T) {
+	// Score is from the side to move point of view,
+	// so colour flipped positions have the same score.
+	var scores []int32
+	network := randomNetwork(16)
+	for _, fen := range []string{
+		"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1",
+		"rnbqkbnr/pppp1ppp/8/4p3/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+	} {
+		pos, _ := PositionFromFEN(fen)
+		eng := NewEngine(pos, nil, Options{})
+		eng.Network = network
+		scores = append(scores, eng.Score())
+	}
+	if scores[0] != scores[1] {
+		t.Errorf("got scores %v for colour flipped positions", scores)
+	}
+
+	// A zero network evaluates everything to zero.
+	pos, _ := PositionFromFEN(FENStartPos)
+	eng := NewEngine(pos, nil, Options{})
+	eng.Network = NewNetwork(8)
+	if score := eng.Score(); score != 0 {
+		t.Errorf("got score %d with a zero network, wanted 0", score)
+	}
+}
+
+func TestNetworkReadWrite(t *testing.T) {
+	want := randomNetwork(8)
+	buf := &bytes.Buffer{}
+	if err := want.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadNetwork(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("network changed after writing and reading")
+	}
+
+	// Corrupted networks.
+	data := buf.Bytes()
+	if _, err := ReadNetwork(bytes.NewReader(data[:len(data)-1])); err == nil {
+		t.Errorf("expected an error for a truncated network")
+	}
+	data[0] = 'X'
+	if _, err := ReadNetwork(bytes.NewReader(data)); err == nil {
+		t.Errorf("expected an error for a bad magic")
+	}
+}
+
+func TestNNUESearch(t *testing.T) {
+	for _, fen := range TestFENs[:4] {
+		pos, _ := PositionFromFEN(fen)
+		tc := NewFixedDepthTimeControl(pos, 4)
+		tc.Start(false)
+		eng := NewEngine(pos, nil, Options{})
+		eng.Network = randomNetwork(16)
+		if _, pv := eng.Play(tc); len(pv) == 0 {
+			t.Errorf("%s: expected a pv", fen)
+		}
+		if pos.String() != fen {
+			t.Errorf("%s: position was modified to %s", fen, pos)
+		}
+	}
+}
@@ -5,6 +5,7 @@
 package engine
 
 import (
+	"reflect"
 	"testing"
 
 	. "bitbucket.org/zurichess/board"
@@ -18,12 +19,12 @@ func TestCachePutGet(t *testing.T) {
 	h1 = murmurMix(h1, c1)
 	h1 = murmurMix(h1, c2)
 
-	ew, eb := Accum{1, 2}, Accum{3, 5}
+	ew, eb := Accum{M: 1, E: 2}, Accum{M: 3, E: 5}
 	c := new(pawnsTable)
 	c.put(h1, ew, eb)
 	if gw, gb, ok := c.get(h1); !ok {
 		t.Errorf("entry not in the cache, expecting a git")
-	} else if ew != gw || eb != gb {
+	} else if !reflect.DeepEqual(ew, gw) || !reflect.DeepEqual(eb, gb) {
 		t.Errorf("got get(%d) == %v, %v; wanted %v. %v", h1, gw, gb, ew, eb)
 	}
 
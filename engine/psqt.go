@@ -0,0 +1,175 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// psqt.go implements the incremental material and piece square scores
+// used by the lazy evaluation.
+//
+// The material and the piece square terms of the evaluation depend
+// only on the pieces and their squares, so they are updated by
+// Engine.DoMove in a few operations. Together with the cached pawns
+// and shelter evaluation they estimate the score without mobility
+// and king safety. If the estimate is far outside the search window
+// the full evaluation is skipped.
+
+package engine
+
+import (
+	. "bitbucket.org/zurichess/board"
+)
+
+const (
+	// lazyMargin is the maximum difference, in centipawns, expected
+	// between the full evaluation and the estimate.
+	lazyMargin = 500
+)
+
+var (
+	// pieceSquares stores the material and the piece square score of
+	// each piece on each square for each king bucket from White's POV.
+	pieceSquares [kingBuckets][PieceArraySize][SquareArraySize]Score
+)
+
+// initPieceSquares computes pieceSquares from the weights.
+func initPieceSquares() {
+	for col := ColorMinValue; col <= ColorMaxValue; col++ {
+		for fig := Pawn; fig <= King; fig++ {
+			pi := ColorFigure(col, fig)
+			for sq := SquareMinValue; sq <= SquareMaxValue; sq++ {
+				for bucket := 0; bucket < kingBuckets; bucket++ {
+					accum := pieceSquare(pi, sq, bucket)
+					pieceSquares[bucket][pi][sq] = Score{
+						M: accum.M * col.Multiplier(),
+						E: accum.E * col.Multiplier(),
+					}
+				}
+			}
+		}
+	}
+}
+
+// pieceSquare returns the material and the piece square score of pi on sq
+// in the king bucket from the POV of pi's owner. The terms are the same
+// as computed by evaluate.
+func pieceSquare(pi Piece, sq Square, bucket int) Accum {
+	var accum Accum
+	us, bb := pi.Color(), sq.Bitboard()
+	switch pi.Figure() {
+	case Pawn:
+		groupByBoard(fPawn, bb, &accum)
+	case Knight:
+		groupByBoard(fKnight, bb, &accum)
+		groupByFigureSq(fKnightFile, fKnightRank, fKnightSquare, us, sq, bucket, &accum)
+	case Bishop:
+		groupByBoard(fBishop, bb, &accum)
+		groupByFigureSq(fBishopFile, fBishopRank, fBishopSquare, us, sq, bucket, &accum)
+	case Rook:
+		groupByBoard(fRook, bb, &accum)
+		groupByFigureSq(fRookFile, fRookRank, fRookSquare, us, sq, bucket, &accum)
+	case Queen:
+		groupByBoard(fQueen, bb, &accum)
+		groupByFigureSq(fQueenFile, fQueenRank, fQueenSquare, us, sq, bucket, &accum)
+	}
+	return accum
+}
+
+// psqtEntry is the material and piece square score of a position.
+type psqtEntry struct {
+	key   uint64 // Zobrist key of the position
+	score Score  // from White's POV
+}
+
+// psqtState keeps the scores of the positions on the search stack.
+type psqtState struct {
+	stack []psqtEntry
+}
+
+// reset clears the stack.
+func (ps *psqtState) reset() {
+	ps.stack = ps.stack[:0]
+}
+
+// pop removes the score of the last position.
+func (ps *psqtState) pop() {
+	if len(ps.stack) != 0 {
+		ps.stack = ps.stack[:len(ps.stack)-1]
+	}
+}
+
+// current returns the score of pos, computing it from scratch
+// if pos was changed without Engine.DoMove.
+func (ps *psqtState) current(pos *Position) Score {
+	n := len(ps.stack)
+	if n == 0 || ps.stack[n-1].key != pos.Zobrist() {
+		ps.pop()
+		ps.stack = append(ps.stack, psqtEntry{pos.Zobrist(), computePieceSquares(pos)})
+		n = len(ps.stack)
+	}
+	return ps.stack[n-1].score
+}
+
+// doMove pushes the score of pos after move was played from the
+// position with Zobrist key prev. The score is updated incrementally
+// if the score of the previous position is available.
+func (ps *psqtState) doMove(prev uint64, move Move, pos *Position) {
+	// The scores of all pieces change if the king changes the bucket.
+	n := len(ps.stack)
+	if n == 0 || ps.stack[n-1].key != prev || fullPSQT && move.Piece().Figure() == King {
+		ps.stack = append(ps.stack, psqtEntry{pos.Zobrist(), computePieceSquares(pos)})
+		return
+	}
+
+	entry := psqtEntry{pos.Zobrist(), ps.stack[n-1].score}
+	if move != NullMove {
+		removePiece(&entry.score, pos, move.Piece(), move.From())
+		putPiece(&entry.score, pos, move.Target(), move.To())
+		removePiece(&entry.score, pos, move.Capture(), move.CaptureSquare())
+		if move.MoveType() == Castling {
+			rook, start, end := CastlingRook(move.To())
+			removePiece(&entry.score, pos, rook, start)
+			putPiece(&entry.score, pos, rook, end)
+		}
+	}
+	ps.stack = append(ps.stack, entry)
+}
+
+// estimate returns the evaluation of pos without mobility and king
+// safety in centipawns from White's POV.
+func (ps *psqtState) estimate(pos *Position, pawns *pawnsTable, materials *materialTable) int32 {
+	score := ps.current(pos)
+	wps, bps := pawns.load(pos)
+	wms, bms, scale := materials.load(pos)
+	e := Eval{position: pos, scale: scale}
+	e.Accum[NoColor].M = score.M + wps.M - bps.M + wms.M - bms.M
+	e.Accum[NoColor].E = score.E + wps.E - bps.E + wms.E - bms.E
+	return e.GetCentipawnsScore()
+}
+
+// computePieceSquares returns the material and the
+// piece square score of pos from White's POV.
+func computePieceSquares(pos *Position) Score {
+	var score Score
+	for bb := pos.ByColor(White) | pos.ByColor(Black); bb != 0; {
+		sq := bb.Pop()
+		putPiece(&score, pos, pos.Get(sq), sq)
+	}
+	return score
+}
+
+// putPiece adds the score of pi on sq in pos to score.
+func putPiece(score *Score, pos *Position, pi Piece, sq Square) {
+	if pi != NoPiece {
+		s := &pieceSquares[kingBucket(pos, pi.Color())][pi][sq]
+		score.M += s.M
+		score.E += s.E
+	}
+}
+
+// removePiece subtracts the score of pi on sq in pos from score.
+func removePiece(score *Score, pos *Position, pi Piece, sq Square) {
+	if pi != NoPiece {
+		s := &pieceSquares[kingBucket(pos, pi.Color())][pi][sq]
+		score.M -= s.M
+		score.E -= s.E
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	. "bitbucket.org/zurichess/board"
+	. "bitbucket.org/zurichess/zurichess/internal/testdata"
+)
+
+// checkPieceSquares checks that the incremental score of eng
+// is the same as the score computed from scratch.
+func checkPieceSquares(t *testing.T, eng *Engine) bool {
+	n := len(eng.psqt.stack)
+	if n == 0 || eng.psqt.stack[n-1].key != eng.Position.Zobrist() {
+		t.Errorf("%v: piece square score is not up to date", eng.Position)
+		return false
+	}
+	if got, want := eng.psqt.stack[n-1].score, computePieceSquares(eng.Position); got != want {
+		t.Errorf("%v: got piece square score %v, wanted %v", eng.Position, got, want)
+		return false
+	}
+	return true
+}
+
+func TestPieceSquaresIncremental(t *testing.T) {
+	for _, game := range TestGames {
+		pos, _ := PositionFromFEN(FENStartPos)
+		eng := NewEngine(pos, nil, Options{})
+		for _, move := range strings.Fields(game) {
+			m, _ := eng.Position.UCIToMove(move)
+			eng.DoMove(m)
+			if !checkPieceSquares(t, eng) {
+				break
+			}
+		}
+	}
+
+	// Kiwipete has castling, en passant and promotions a few plies deep.
+	pos, _ := PositionFromFEN(FENKiwipete)
+	eng := NewEngine(pos, nil, Options{})
+	eng.psqt.current(pos)
+	var walk func(depth int)
+	walk = func(depth int) {
+		for _, m := range append(LegalMoves(eng.Position), NullMove) {
+			eng.DoMove(m)
+			if checkPieceSquares(t, eng) && depth > 1 {
+				walk(depth - 1)
+			}
+			eng.UndoMove()
+		}
+	}
+	walk(3)
+	if len(eng.psqt.stack) != 1 {
+		t.Errorf("got %d scores on the stack, wanted 1", len(eng.psqt.stack))
+	}
+}
+
+func TestPieceSquaresSymmetry(t *testing.T) {
+	for _, fen := range TestFENs {
+		pos, _ := PositionFromFEN(fen)
+		other, _ := PositionFromFEN(flipFEN(fen))
+		if s, o := computePieceSquares(pos), computePieceSquares(other); s.M != -o.M || s.E != -o.E {
+			t.Errorf("%s: got piece square scores %v and %v for the flipped position", fen, s, o)
+		}
+	}
+}
+
+func TestLazyEstimate(t *testing.T) {
+	// The estimate is within lazyMargin of the full evaluation.
+	for _, fen := range testPositions() {
+		pos, _ := PositionFromFEN(fen)
+		if mayBeKnownEndgame(pos) {
+			continue
+		}
+		eng := NewEngine(pos, nil, Options{})
+		score := Evaluate(pos).GetCentipawnsScore()
+		estimate := eng.psqt.estimate(pos, eng.pawns, eng.materials)
+		if estimate-lazyMargin >= score || score >= estimate+lazyMargin {
+			t.Errorf("%s: got estimate %d, full evaluation %d", fen, estimate, score)
+		}
+	}
+}
+
+func TestEvalCache(t *testing.T) {
+	for _, fen := range TestFENs {
+		pos, _ := PositionFromFEN(fen)
+		eng := NewEngine(pos, nil, Options{})
+		want := Evaluate(pos).GetCentipawnsScore() * pos.Us().Multiplier()
+		for i := 0; i < 2; i++ {
+			if got := eng.Score(); got != want {
+				t.Errorf("%s: got score %d, wanted %d", fen, got, want)
+			}
+		}
+		if eng.Stats.Evaluations != 1 || eng.Stats.EvalCacheHits != 1 {
+			t.Errorf("%s: got %d evaluations and %d cache hits, wanted 1 and 1",
+				fen, eng.Stats.Evaluations, eng.Stats.EvalCacheHits)
+		}
+	}
+}
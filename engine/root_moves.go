@@ -0,0 +1,116 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// root_moves.go implements the all moves mode which reports
+// a score for every legal root move.
+//
+// The principal variation search proves only bounds for most root
+// moves. After each depth the moves without an exact score are
+// searched again, one by one, with a full window. The moves scored
+// much lower than the best move are verified at a reduced depth.
+
+package engine
+
+import (
+	"sort"
+
+	. "bitbucket.org/zurichess/board"
+)
+
+const (
+	// allMovesMargin is how far below the best score, in centipawns,
+	// a root move is considered clearly losing.
+	allMovesMargin = 300
+)
+
+// RootMove is the score of a root move.
+type RootMove struct {
+	Move  Move
+	Score int32   // score from the side to move POV
+	Bound string  // exact, lowerbound or upperbound
+	Depth int32   // depth of the search that computed the score
+	Nodes uint64  // nodes searched for this move at the last depth
+	Share float64 // fraction of the root nodes searched for this move
+}
+
+// RootMovesLogger is implemented by the loggers that
+// report the root moves in the all moves mode.
+type RootMovesLogger interface {
+	// PrintRootMoves logs the root moves, ordered by score,
+	// after iterative deepening completed one depth.
+	PrintRootMoves(stats Stats, moves []RootMove)
+}
+
+// rootScore is the score of a root move from the last search.
+type rootScore struct {
+	score int32
+	bound hashFlags
+}
+
+// boundName returns the UCI name of bound.
+func boundName(bound hashFlags) string {
+	switch bound {
+	case failedLow:
+		return "upperbound"
+	case failedHigh:
+		return "lowerbound"
+	}
+	return "exact"
+}
+
+// searchRootMoves computes a score for each root move after
+// the search at depth found the best score. If the search is
+// stopped, the remaining moves keep the bounds from the search.
+func (eng *Engine) searchRootMoves(depth, best int32) []RootMove {
+	only, ignore := eng.onlyRootMoves, eng.ignoreRootMoves
+	defer func() { eng.onlyRootMoves, eng.ignoreRootMoves = only, ignore }()
+	eng.ignoreRootMoves = nil
+
+	// The nodes of the verification searches are not accounted for
+	// the root moves, so they don't change the time management.
+	rootNodes := eng.rootNodes
+	eng.rootNodes = make(map[Move]uint64)
+
+	var legal []Move
+	for _, m := range LegalMoves(eng.Position) {
+		if !eng.isIgnoredRootMove(m) {
+			legal = append(legal, m)
+		}
+	}
+
+	var moves []RootMove
+	for _, m := range legal {
+		rs, ok := eng.rootScores[m]
+		d := depth
+		if !eng.stopped && (!ok || rs.bound != exact) {
+			if ok && rs.score < best-allMovesMargin {
+				d = max(depth/2, 1)
+			}
+			eng.onlyRootMoves = []Move{m}
+			if score := eng.searchTree(-InfinityScore, InfinityScore, d); !eng.stopped {
+				rs, ok = rootScore{score, exact}, true
+			} else {
+				d = depth
+			}
+		}
+		if ok {
+			moves = append(moves, RootMove{
+				Move:  m,
+				Score: rs.score,
+				Bound: boundName(rs.bound),
+				Depth: d,
+			})
+		}
+	}
+
+	eng.rootNodes = rootNodes
+	for i := range moves {
+		moves[i].Nodes = eng.rootNodes[moves[i].Move]
+		moves[i].Share = eng.rootNodesShare(moves[i].Move)
+	}
+	sort.SliceStable(moves, func(i, j int) bool {
+		return moves[i].Score > moves[j].Score
+	})
+	return moves
+}
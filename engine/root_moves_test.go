@@ -0,0 +1,149 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	. "bitbucket.org/zurichess/board"
+	. "bitbucket.org/zurichess/zurichess/internal/testdata"
+)
+
+// rootMovesLogger records the root moves and the principal variations.
+type rootMovesLogger struct {
+	pvLogger
+	rootMoves [][]RootMove
+}
+
+func (l *rootMovesLogger) PrintRootMoves(stats Stats, moves []RootMove) {
+	l.rootMoves = append(l.rootMoves, moves)
+}
+
+func TestAllMoves(t *testing.T) {
+	if tuning {
+		t.Skip("the tuner has no weights")
+	}
+	reduced := 0
+	for _, fen := range append([]string{FENKiwipete}, TestFENs[:4]...) {
+		pos, _ := PositionFromFEN(fen)
+		log := &rootMovesLogger{}
+		eng := NewEngine(pos, log, Options{AllMoves: true})
+		eng.HashTable = NewHashTable(1)
+		tc := NewFixedDepthTimeControl(pos, 5)
+		tc.Start(false)
+		score, pv := eng.Play(tc)
+
+		if len(log.rootMoves) != 5 {
+			t.Fatalf("%s: got %d reports, wanted one for each depth", fen, len(log.rootMoves))
+		}
+		moves := log.rootMoves[len(log.rootMoves)-1]
+		if len(moves) != len(LegalMoves(pos)) {
+			t.Errorf("%s: got %d root moves, wanted %d", fen, len(moves), len(LegalMoves(pos)))
+		}
+		seen := make(map[Move]bool)
+		for i, rm := range moves {
+			if seen[rm.Move] {
+				t.Errorf("%s: move %v reported twice", fen, rm.Move)
+			}
+			seen[rm.Move] = true
+			if rm.Bound != "exact" {
+				t.Errorf("%s: got bound %s for %v, wanted exact", fen, rm.Bound, rm.Move)
+			}
+			if i > 0 && rm.Score > moves[i-1].Score {
+				t.Errorf("%s: root moves are not sorted by score", fen)
+			}
+			if rm.Depth < 5 {
+				reduced++
+				if rm.Score >= score {
+					t.Errorf("%s: best move %v was verified at reduced depth", fen, rm.Move)
+				}
+			}
+		}
+		if moves[0].Move != pv[0] || moves[0].Score != score {
+			t.Errorf("%s: got best root move %v %d, wanted %v %d", fen, moves[0].Move, moves[0].Score, pv[0], score)
+		}
+	}
+	if reduced == 0 {
+		t.Errorf("expected some clearly losing moves verified at reduced depth")
+	}
+}
+
+func TestAllMovesSearchMoves(t *testing.T) {
+	pos, _ := PositionFromFEN(FENStartPos)
+	var only []Move
+	for _, s := range []string{"e2e4", "d2d4", "g1f3"} {
+		m, _ := pos.UCIToMove(s)
+		only = append(only, m)
+	}
+
+	log := &rootMovesLogger{}
+	eng := NewEngine(pos, log, Options{AllMoves: true, MultiPV: 2})
+	tc := NewFixedDepthTimeControl(pos, 4)
+	tc.Start(false)
+	eng.PlayMoves(tc, only)
+
+	for _, moves := range log.rootMoves {
+		if len(moves) != len(only) {
+			t.Errorf("got %d root moves, wanted %d", len(moves), len(only))
+		}
+	}
+	if len(eng.ignoreRootMoves) > 2 || len(eng.onlyRootMoves) != len(only) {
+		t.Errorf("root moves were not restored")
+	}
+}
+
+func TestAllMovesRootNodes(t *testing.T) {
+	pos, _ := PositionFromFEN(FENKiwipete)
+	eng := NewEngine(pos, nil, Options{AllMoves: true})
+	tc := NewFixedDepthTimeControl(pos, 4)
+	tc.Start(false)
+	score, _ := eng.Play(tc)
+
+	want := make(map[Move]uint64)
+	for m, n := range eng.rootNodes {
+		want[m] = n
+	}
+	for _, rm := range eng.searchRootMoves(4, score) {
+		if rm.Nodes != want[rm.Move] {
+			t.Errorf("got %d nodes for %v, wanted %d", rm.Nodes, rm.Move, want[rm.Move])
+		}
+	}
+	if !reflect.DeepEqual(eng.rootNodes, want) {
+		t.Errorf("the verification searches changed the root nodes")
+	}
+}
+
+func TestJSONLoggerRootMoves(t *testing.T) {
+	buf := &bytes.Buffer{}
+	pos, _ := PositionFromFEN(FENStartPos)
+	eng := NewEngine(pos, nil, Options{AllMoves: true})
+	eng.Log = MultiLogger(&NulLogger{}, NewJSONLogger(buf, eng))
+	tc := NewFixedDepthTimeControl(pos, 3)
+	tc.Start(false)
+	eng.Play(tc)
+
+	num := 0
+	scan := bufio.NewScanner(buf)
+	for scan.Scan() {
+		var ev LogEvent
+		if err := json.Unmarshal(scan.Bytes(), &ev); err != nil {
+			t.Fatalf("cannot parse %q: %v", scan.Text(), err)
+		}
+		if ev.Event != "rootmove" {
+			continue
+		}
+		if ev.Score == nil || ev.Move == "" || ev.MoveNum < 1 || ev.MoveNum > 20 {
+			t.Errorf("got unexpected event %+v", ev)
+		}
+		num++
+	}
+	if num != 3*20 {
+		t.Errorf("got %d root moves, wanted 20 for each depth", num)
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	. "bitbucket.org/zurichess/board"
+)
+
+var (
+	sanFigureToSymbol = [...]string{"", "", "N", "B", "R", "Q", "K"}
+	sanSymbolToFigure = map[byte]Figure{'N': Knight, 'B': Bishop, 'R': Rook, 'Q': Queen, 'K': King}
+)
+
+// MoveToSAN converts the legal move m in pos to Standard Algebraic Notation,
+// e.g. e4, Nbd7, exd5, O-O, e8=Q+, Qh4#.
+// https://en.wikipedia.org/wiki/Algebraic_notation_(chess)
+func MoveToSAN(pos *Position, m Move) string {
+	var r string
+	fig := m.Piece().Figure()
+	if m.MoveType() == Castling {
+		if m.To().File() > m.From().File() {
+			r = "O-O"
+		} else {
+			r = "O-O-O"
+		}
+	} else if fig == Pawn {
+		if m.Capture() != NoPiece {
+			r = m.From().String()[:1] + "x"
+		}
+		r += m.To().String()
+		if m.MoveType() == Promotion {
+			r += "=" + sanFigureToSymbol[m.Promotion().Figure()]
+		}
+	} else {
+		r = sanFigureToSymbol[fig] + disambiguate(pos, m)
+		if m.Capture() != NoPiece {
+			r += "x"
+		}
+		r += m.To().String()
+	}
+
+	pos.DoMove(m)
+	if pos.IsChecked(pos.Us()) {
+		if pos.HasLegalMoves() {
+			r += "+"
+		} else {
+			r += "#"
+		}
+	}
+	pos.UndoMove()
+	return r
+}
+
+// disambiguate returns the file, the rank or the square of the origin
+// of m if other pieces of the same kind can move to the same square.
+func disambiguate(pos *Position, m Move) string {
+	sameFile, sameRank, ambiguous := false, false, false
+	for _, other := range LegalMoves(pos) {
+		if other == m || other.Piece() != m.Piece() || other.To() != m.To() {
+			continue
+		}
+		ambiguous = true
+		sameFile = sameFile || other.From().File() == m.From().File()
+		sameRank = sameRank || other.From().Rank() == m.From().Rank()
+	}
+
+	from := m.From().String()
+	if !ambiguous {
+		return ""
+	} else if !sameFile {
+		return from[:1]
+	} else if !sameRank {
+		return from[1:]
+	}
+	return from
+}
+
+// PVToSAN converts the moves in pv played from pos to
+// Standard Algebraic Notation. pos is not modified.
+func PVToSAN(pos *Position, pv []Move) []string {
+	san := make([]string, len(pv))
+	for i, m := range pv {
+		san[i] = MoveToSAN(pos, m)
+		pos.DoMove(m)
+	}
+	for range pv {
+		pos.UndoMove()
+	}
+	return san
+}
+
+// SANToMove parses the move s in Standard Algebraic Notation in pos.
+// The parser is lenient: check, mate and annotation symbols are ignored,
+// the capture symbol is optional and castling can be written with zeros.
+func SANToMove(pos *Position, s string) (Move, error) {
+	san := strings.TrimRight(s, "+#!?")
+	if san == "O-O" || san == "0-0" || san == "O-O-O" || san == "0-0-0" {
+		kingSide := len(san) == 3
+		for _, m := range LegalMoves(pos) {
+			if m.MoveType() == Castling && (m.To().File() > m.From().File()) == kingSide {
+				return m, nil
+			}
+		}
+		return NullMove, fmt.Errorf("%s: castling is not legal", s)
+	}
+
+	// Parse the moving figure.
+	fig := Pawn
+	if len(san) > 0 {
+		if f, ok := sanSymbolToFigure[san[0]]; ok {
+			fig = f
+			san = san[1:]
+		}
+	}
+
+	// Parse the promotion.
+	promotion := NoFigure
+	if n := len(san); n > 0 {
+		if f, ok := sanSymbolToFigure[san[n-1]]; ok && f != King && fig == Pawn {
+			promotion = f
+			san = strings.TrimSuffix(san[:n-1], "=")
+		}
+	}
+
+	// Parse the destination square.
+	if len(san) < 2 {
+		return NullMove, fmt.Errorf("%s: invalid move", s)
+	}
+	to, err := SquareFromString(san[len(san)-2:])
+	if err != nil {
+		return NullMove, fmt.Errorf("%s: %v", s, err)
+	}
+
+	// What remains is the origin, possibly incomplete, and the capture symbol.
+	from := strings.TrimSuffix(san[:len(san)-2], "x")
+	file, rank := -1, -1
+	for _, c := range from {
+		if 'a' <= c && c <= 'h' && file == -1 && rank == -1 {
+			file = int(c - 'a')
+		} else if '1' <= c && c <= '8' && rank == -1 {
+			rank = int(c - '1')
+		} else {
+			return NullMove, fmt.Errorf("%s: invalid move", s)
+		}
+	}
+
+	found := NullMove
+	for _, m := range LegalMoves(pos) {
+		if m.MoveType() == Castling || m.Piece().Figure() != fig || m.To() != to ||
+			m.Promotion().Figure() != promotion ||
+			(file != -1 && m.From().File() != file) ||
+			(rank != -1 && m.From().Rank() != rank) {
+			continue
+		}
+		if found != NullMove {
+			return NullMove, fmt.Errorf("%s: ambiguous move", s)
+		}
+		found = m
+	}
+	if found == NullMove {
+		return NullMove, fmt.Errorf("%s: illegal move", s)
+	}
+	return found, nil
+}
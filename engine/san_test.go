@@ -0,0 +1,142 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	. "bitbucket.org/zurichess/board"
+	. "bitbucket.org/zurichess/zurichess/internal/testdata"
+)
+
+func TestMoveToSAN(t *testing.T) {
+	data := []struct {
+		fen  string
+		move string
+		san  string
+	}{
+		{FENStartPos, "e2e4", "e4"},
+		{FENStartPos, "g1f3", "Nf3"},
+		{"r1bqkbnr/pppp1ppp/2n5/4p3/3PP3/5N2/PPP2PPP/RNBQKB1R b KQkq - 0 3", "e5d4", "exd4"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "e1g1", "O-O"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", "e8c8", "O-O-O"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "a1a8", "Rxa8+"},
+		{"8/P6k/8/8/8/8/8/K7 w - - 0 1", "a7a8q", "a8=Q"},
+		{"8/P5k1/8/8/8/8/8/K7 w - - 0 1", "a7a8n", "a8=N"},
+		{"rnbqkbnr/ppppp2p/5p2/6p1/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 3", "d1h5", "Qh5#"},
+		{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 2", "e5d6", "exd6"},
+		// Disambiguation by file, by rank and by square.
+		{"4k3/8/8/8/8/8/4K3/R6R w - - 0 1", "a1d1", "Rad1"},
+		{"4k3/R7/8/8/8/8/8/R3K3 w - - 0 1", "a1a4", "R1a4"},
+		{"k7/8/8/8/8/2Q1Q3/8/2Q1K3 w - - 0 1", "c3d2", "Qc3d2"},
+		// Pinned pieces are not ambiguous.
+		{"4k3/4r3/8/8/8/8/4N3/2N1K3 w - - 0 1", "c1d3", "Nd3"},
+	}
+
+	for _, d := range data {
+		pos, err := PositionFromFEN(d.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := pos.UCIToMove(d.move)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if san := MoveToSAN(pos, m); san != d.san {
+			t.Errorf("%s %s: got %s, wanted %s", d.fen, d.move, san, d.san)
+		}
+		if pos.String() != d.fen {
+			t.Errorf("%s: position was modified to %s", d.fen, pos)
+		}
+	}
+}
+
+func TestPVToSAN(t *testing.T) {
+	pos, _ := PositionFromFEN(FENStartPos)
+	var pv []Move
+	for _, s := range strings.Fields("f2f3 e7e5 g2g4 d8h4") {
+		m, _ := pos.UCIToMove(s)
+		pv = append(pv, m)
+		pos.DoMove(m)
+	}
+	for range pv {
+		pos.UndoMove()
+	}
+
+	if san := strings.Join(PVToSAN(pos, pv), " "); san != "f3 e5 g4 Qh4#" {
+		t.Errorf("got %s, wanted f3 e5 g4 Qh4#", san)
+	}
+	if pos.String() != FENStartPos {
+		t.Errorf("position was modified to %s", pos)
+	}
+}
+
+func TestSANToMove(t *testing.T) {
+	data := []struct {
+		fen  string
+		san  string
+		move string
+	}{
+		{FENStartPos, "e4", "e2e4"},
+		{FENStartPos, "Nf3", "g1f3"},
+		{FENStartPos, "Ngf3", "g1f3"},
+		{FENStartPos, "Ng1f3!?", "g1f3"},
+		{"r1bqkbnr/pppp1ppp/2n5/4p3/3PP3/5N2/PPP2PPP/RNBQKB1R b KQkq - 0 3", "exd4", "e5d4"},
+		{"r1bqkbnr/pppp1ppp/2n5/4p3/3PP3/5N2/PPP2PPP/RNBQKB1R b KQkq - 0 3", "ed4", "e5d4"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "O-O", "e1g1"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", "0-0-0", "e8c8"},
+		{"8/P6k/8/8/8/8/8/K7 w - - 0 1", "a8=Q", "a7a8q"},
+		{"8/P6k/8/8/8/8/8/K7 w - - 0 1", "a8N", "a7a8n"},
+		{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 2", "exd6", "e5d6"},
+		{"4k3/8/8/8/8/8/4K3/R6R w - - 0 1", "Rad1", "a1d1"},
+		{"4k3/R7/8/8/8/8/8/R3K3 w - - 0 1", "R1a4", "a1a4"},
+		{"k7/8/8/8/8/2Q1Q3/8/2Q1K3 w - - 0 1", "Qc3d2", "c3d2"},
+		{"4k3/4r3/8/8/8/8/4N3/2N1K3 w - - 0 1", "Nd3", "c1d3"},
+	}
+
+	for _, d := range data {
+		pos, _ := PositionFromFEN(d.fen)
+		m, err := SANToMove(pos, d.san)
+		if err != nil {
+			t.Errorf("%s %s: %v", d.fen, d.san, err)
+		} else if m.UCI() != d.move {
+			t.Errorf("%s %s: got %s, wanted %s", d.fen, d.san, m.UCI(), d.move)
+		}
+	}
+}
+
+func TestSANToMoveErrors(t *testing.T) {
+	data := []struct {
+		fen string
+		san string
+	}{
+		{FENStartPos, "e5"},
+		{FENStartPos, "O-O"},
+		{FENStartPos, "Nd4"},
+		{FENStartPos, "x"},
+		{FENStartPos, ""},
+		{"4k3/8/8/8/8/8/4K3/R6R w - - 0 1", "Rd1"}, // ambiguous
+		{"8/P6k/8/8/8/8/8/K7 w - - 0 1", "a8"},     // missing promotion
+	}
+	for _, d := range data {
+		pos, _ := PositionFromFEN(d.fen)
+		if m, err := SANToMove(pos, d.san); err == nil {
+			t.Errorf("%s %s: got %v, expected error", d.fen, d.san, m)
+		}
+	}
+}
+
+func TestSANRoundTrip(t *testing.T) {
+	for _, fen := range TestFENs {
+		pos, _ := PositionFromFEN(fen)
+		for _, m := range LegalMoves(pos) {
+			san := MoveToSAN(pos, m)
+			if got, err := SANToMove(pos, san); err != nil || got != m {
+				t.Errorf("%s %s: got %v %v, wanted %v", fen, san, got, err, m)
+			}
+		}
+	}
+}
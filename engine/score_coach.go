@@ -6,6 +6,9 @@
 
 package engine
 
+// tuning is true when the engine is built for the tuner.
+const tuning = true
+
 // Score represents a pair of mid and end game scores.
 type Score struct {
 	M, E int32 // mid game, end game
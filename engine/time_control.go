@@ -11,9 +11,20 @@ import (
 )
 
 const (
-	defaultMovesToGo = 35 // default number of more moves expected to play
-	infinite         = 1000000000 * time.Second
-	overhead         = 20 * time.Millisecond
+	// DefaultMovesToGo is the default number of more moves expected to play.
+	DefaultMovesToGo = 35
+	// DefaultMoveOverhead is the default time reserved for communication with the GUI.
+	DefaultMoveOverhead = 20 * time.Millisecond
+	// DeterministicNPS is the speed, in nodes per second, used to
+	// convert the time limits to node limits in the deterministic mode.
+	DeterministicNPS = 1000000
+
+	infinite = 1000000000 * time.Second
+
+	scoreDropMargin   = 20  // score drops under this margin are ignored
+	dominantMoveShare = 0.9 // share of nodes when the best move dominates the search
+	minTimeScale      = 0.5 // minimum scale of the allocated time
+	maxTimeScale      = 2.5 // maximum scale of the allocated time
 )
 
 // atomicFlag is an atomic bool that can only be set.
@@ -41,11 +52,22 @@ type TimeControl struct {
 	WTime, WInc time.Duration // time and increment for white.
 	BTime, BInc time.Duration // time and increment for black
 	Depth       int32         // maximum depth search (including)
-	MovesToGo   int32         // number of remaining moves, defaults to defaultMovesToGo
+	Nodes       uint64        // maximum number of nodes to search, 0 for no limit
+	MovesToGo   int32         // number of remaining moves, defaults to DefaultMovesToGo
+
+	// Deterministic ignores the clock. The time limits are converted
+	// to node limits using DeterministicNPS when the search is started.
+	Deterministic bool
+
+	Overhead        time.Duration // time reserved for communication, defaults to DefaultMoveOverhead
+	MinThinkingTime time.Duration // minimum time to think if the clock allows it
+
+	Mode           ClockMode     // how the clock handles the delay, defaults to FischerClock
+	WDelay, BDelay time.Duration // delay for white and black, used by DelayClock and BronsteinClock
 
-	sideToMove Color
-	time, inc  time.Duration // time and increment for us
-	limit      time.Duration
+	sideToMove       Color
+	time, inc, delay time.Duration // time, increment and delay for us
+	limit            time.Duration
 
 	predicted bool       // true if this move was predicted
 	branch    int        // branching factor, multiplied by 16
@@ -53,7 +75,14 @@ type TimeControl struct {
 	stopped   atomicFlag // true to stop the search
 	ponderhit atomicFlag // true if ponder was successful
 
+	instability float64 // decaying number of best move changes
+
+	// The deadlines are updated by Feedback from the search
+	// and by PonderHit from the GUI, so they are protected by lock.
+	lock           sync.Mutex
+	scale          float64       // how much of searchTime to use based on search feedback
 	searchTime     time.Duration // alocated time for this move
+	searchStart    time.Time     // when the deadlines were last computed
 	searchDeadline time.Time     // don't go to the next depth after this deadline
 	stopDeadline   time.Time     // abort search after this deadline
 }
@@ -67,10 +96,12 @@ func NewTimeControl(pos *Position, predicted bool) *TimeControl {
 		BTime:      infinite,
 		BInc:       0,
 		Depth:      64,
-		MovesToGo:  defaultMovesToGo,
+		MovesToGo:  DefaultMovesToGo,
+		Overhead:   DefaultMoveOverhead,
 		sideToMove: pos.Us(),
 		predicted:  predicted,
 		branch:     32,
+		scale:      1,
 	}
 }
 
@@ -92,19 +123,38 @@ func NewDeadlineTimeControl(pos *Position, deadline time.Duration) *TimeControl
 }
 
 // thinkingTime calculates how much time to think this round.
-// t is the remaining time, i is the increment.
 func (tc *TimeControl) thinkingTime() time.Duration {
 	// The formula allows engine to use more of time in the begining
 	// and rely more on the increment later.
 	tmp := time.Duration(tc.MovesToGo)
 	tt := (tc.time + (tmp-1)*tc.inc) / tmp
 
+	switch tc.Mode {
+	case DelayClock:
+		// The clock doesn't run during the delay.
+		tt += tc.delay
+	case BronsteinClock:
+		// The time used, up to the delay, is given back after the move.
+		// Using less than the delay wastes it.
+		tt = (tc.time + (tmp-1)*(tc.inc+tc.delay)) / tmp
+		if tt < tc.delay {
+			tt = tc.delay
+		}
+	case HourglassClock:
+		// The time used is added to the opponent's clock,
+		// so each second spent counts twice.
+		tt /= 2
+	}
+
 	if tt < 0 {
 		return 0
 	}
 	if tc.predicted {
 		tt = tt * 4 / 3
 	}
+	if tt < tc.MinThinkingTime {
+		tt = tc.MinThinkingTime
+	}
 	if tt < tc.limit {
 		return tt
 	}
@@ -115,22 +165,26 @@ func (tc *TimeControl) thinkingTime() time.Duration {
 // Should start as soon as possible to set the correct time.
 func (tc *TimeControl) Start(ponder bool) {
 	if tc.sideToMove == White {
-		tc.time, tc.inc = tc.WTime, tc.WInc
+		tc.time, tc.inc, tc.delay = tc.WTime, tc.WInc, tc.WDelay
 	} else {
-		tc.time, tc.inc = tc.BTime, tc.BInc
+		tc.time, tc.inc, tc.delay = tc.BTime, tc.BInc, tc.BDelay
 	}
 
 	// Calcuates the last moment when the search should be stopped.
-	if tc.time > 2*overhead {
-		tc.limit = tc.time - overhead
-	} else if tc.time > overhead {
-		tc.limit = overhead
+	if tc.time > 2*tc.Overhead {
+		tc.limit = tc.time - tc.Overhead
+	} else if tc.time > tc.Overhead {
+		tc.limit = tc.Overhead
 	} else {
 		tc.limit = tc.time
 	}
 
 	// If there are still many moves to go, don't use all the time.
 	tc.limit /= time.Duration(min(tc.MovesToGo, 5))
+	if tc.Mode == DelayClock {
+		// The delay is free.
+		tc.limit += tc.delay
+	}
 
 	// Increase the branchFactor a bit to be on the
 	// safe side when there are only a few moves left.
@@ -142,14 +196,23 @@ func (tc *TimeControl) Start(ponder bool) {
 
 	tc.stopped = atomicFlag{flag: false}
 	tc.ponderhit = atomicFlag{flag: !ponder}
+	tc.instability = 0
+	tc.scale = 1
 
 	tc.searchTime = tc.thinkingTime()
 	tc.updateDeadlines() // deadlines are ignored while pondering (ponderHit == false)
+
+	if tc.Deterministic && tc.time != infinite {
+		// Search at most the nodes searched in searchTime.
+		nodes := uint64(tc.searchTime.Seconds()*DeterministicNPS) + 1
+		if tc.Nodes == 0 || nodes < tc.Nodes {
+			tc.Nodes = nodes
+		}
+	}
 }
 
 func (tc *TimeControl) updateDeadlines() {
-	now := time.Now()
-	tc.searchDeadline = now.Add(tc.searchTime / time.Duration(tc.branch/16))
+	tc.searchStart = time.Now()
 
 	// stopDeadline is when to abort the search in case of an explosion.
 	// We give a large overhead here so the search is not aborted very often.
@@ -157,22 +220,87 @@ func (tc *TimeControl) updateDeadlines() {
 	if deadline > tc.limit {
 		deadline = tc.limit
 	}
-	tc.stopDeadline = now.Add(deadline)
+	tc.stopDeadline = tc.searchStart.Add(deadline)
+	tc.updateSearchDeadline()
+}
+
+// updateSearchDeadline computes searchDeadline from the search time scaled
+// by the search feedback. searchDeadline is never after stopDeadline.
+func (tc *TimeControl) updateSearchDeadline() {
+	searchTime := time.Duration(float64(tc.searchTime) * tc.scale)
+	tc.searchDeadline = tc.searchStart.Add(searchTime / time.Duration(tc.branch/16))
+	if tc.searchDeadline.After(tc.stopDeadline) {
+		tc.searchDeadline = tc.stopDeadline
+	}
+}
+
+// Feedback adjusts the time allocated for this move based on how
+// the search progressed during the last completed depth.
+//
+// bestMoveChanged is true if the best move differs from the previous depth.
+// scoreDrop is how much the score decreased since the previous depth (negative if it increased).
+// bestMoveShare is the fraction of root nodes spent searching the best move.
+//
+// More time is used when the best move is unstable or the score drops,
+// less time is used when a single move dominates the search.
+// The search is still aborted at the same stop deadline.
+func (tc *TimeControl) Feedback(bestMoveChanged bool, scoreDrop int32, bestMoveShare float64) {
+	tc.instability /= 2
+	if bestMoveChanged {
+		tc.instability++
+	}
+
+	scale := 1 + tc.instability/2
+	if scoreDrop > scoreDropMargin {
+		// Give the search a chance to find a better move.
+		scale *= 1 + float64(min(scoreDrop, 4*scoreDropMargin))/float64(4*scoreDropMargin)
+	}
+	if tc.instability < 0.25 && scoreDrop <= 0 && bestMoveShare >= dominantMoveShare {
+		// The best move was stable for a few depths and
+		// the other moves were refuted quickly.
+		scale /= 2
+	}
+	if scale < minTimeScale {
+		scale = minTimeScale
+	} else if scale > maxTimeScale {
+		scale = maxTimeScale
+	}
+
+	tc.lock.Lock()
+	tc.scale = scale
+	tc.updateSearchDeadline()
+	tc.lock.Unlock()
 }
 
 // NextDepth returns true if search can start at depth.
 // In any case Stopped() will return false.
 func (tc *TimeControl) NextDepth(depth int32) bool {
 	tc.currDepth = depth
-	return tc.currDepth <= tc.Depth && !tc.hasStopped(tc.searchDeadline)
+	deadline, _ := tc.deadlines()
+	return tc.currDepth <= tc.Depth && !tc.hasStopped(deadline)
 }
 
 // PonderHit switch to our time control.
 func (tc *TimeControl) PonderHit() {
+	tc.lock.Lock()
 	tc.updateDeadlines()
+	tc.lock.Unlock()
 	tc.ponderhit.set()
 }
 
+// deadlines returns the search and the stop deadlines.
+func (tc *TimeControl) deadlines() (time.Time, time.Time) {
+	tc.lock.Lock()
+	defer tc.lock.Unlock()
+	return tc.searchDeadline, tc.stopDeadline
+}
+
+// Pondering returns true if the search was started
+// to ponder and PonderHit was not called.
+func (tc *TimeControl) Pondering() bool {
+	return !tc.ponderhit.get()
+}
+
 // Stop marks the search as stopped.
 func (tc *TimeControl) Stop() {
 	tc.stopped.set()
@@ -187,17 +315,28 @@ func (tc *TimeControl) hasStopped(deadline time.Time) bool {
 		// Use a cached value if available.
 		return true
 	}
-	if tc.ponderhit.get() && time.Now().After(deadline) {
+	if !tc.Deterministic && tc.ponderhit.get() && time.Now().After(deadline) {
 		// Stop search if no longer pondering and deadline as passed.
 		return true
 	}
 	return false
 }
 
+// nodesExceeded returns true if the search has stopped because
+// more than Nodes nodes were searched. Like the time limit, the
+// node limit is ignored for the first few depths.
+func (tc *TimeControl) nodesExceeded(nodes uint64) bool {
+	if tc.Nodes == 0 || nodes < tc.Nodes || tc.currDepth <= 2 {
+		return false
+	}
+	tc.stopped.set()
+	return true
+}
+
 // Stopped returns true if the search has stopped because
 // Stop() was called or the time has ran out.
 func (tc *TimeControl) Stopped() bool {
-	if !tc.hasStopped(tc.stopDeadline) {
+	if _, deadline := tc.deadlines(); !tc.hasStopped(deadline) {
 		return false
 	}
 	// Time has ran out so flip the stopped flag.
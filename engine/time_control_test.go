@@ -0,0 +1,123 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package engine
+
+import (
+	"testing"
+	"time"
+
+	. "bitbucket.org/zurichess/board"
+)
+
+func newFeedbackTimeControl() *TimeControl {
+	pos, _ := PositionFromFEN(FENStartPos)
+	tc := NewTimeControl(pos, false)
+	tc.WTime = 60 * time.Second
+	tc.BTime = 60 * time.Second
+	tc.Start(false)
+	return tc
+}
+
+func TestFeedbackUnstable(t *testing.T) {
+	tc := newFeedbackTimeControl()
+	deadline := tc.searchDeadline
+	tc.Feedback(true, 0, 0.3)
+	if !tc.searchDeadline.After(deadline) {
+		t.Errorf("expected more time when the best move changes")
+	}
+}
+
+func TestFeedbackScoreDrop(t *testing.T) {
+	tc := newFeedbackTimeControl()
+	deadline := tc.searchDeadline
+	tc.Feedback(false, 100, 0.3)
+	if !tc.searchDeadline.After(deadline) {
+		t.Errorf("expected more time when the score drops")
+	}
+}
+
+func TestFeedbackDominantMove(t *testing.T) {
+	tc := newFeedbackTimeControl()
+	deadline := tc.searchDeadline
+	tc.Feedback(false, 0, 0.95)
+	if !tc.searchDeadline.Before(deadline) {
+		t.Errorf("expected less time when the best move dominates")
+	}
+}
+
+func TestFeedbackStopDeadline(t *testing.T) {
+	tc := newFeedbackTimeControl()
+	for i := 0; i < 10; i++ {
+		tc.Feedback(true, 1000, 0)
+	}
+	if tc.searchDeadline.After(tc.stopDeadline) {
+		t.Errorf("search deadline %v is after stop deadline %v", tc.searchDeadline, tc.stopDeadline)
+	}
+	if tc.scale > maxTimeScale {
+		t.Errorf("got scale %f, expected at most %f", tc.scale, maxTimeScale)
+	}
+}
+
+func TestOverheadAndMinThinkingTime(t *testing.T) {
+	pos, _ := PositionFromFEN(FENStartPos)
+	tc := NewDeadlineTimeControl(pos, time.Second)
+	tc.Overhead = 300 * time.Millisecond
+	tc.Start(false)
+	if tc.searchTime != 700*time.Millisecond {
+		t.Errorf("got search time %v, wanted 700ms", tc.searchTime)
+	}
+
+	tc = NewTimeControl(pos, false)
+	tc.WTime = 60 * time.Second
+	tc.MinThinkingTime = 2 * time.Second
+	tc.Start(false)
+	if tc.searchTime != 2*time.Second {
+		t.Errorf("got search time %v, wanted 2s", tc.searchTime)
+	}
+
+	// The minimum thinking time cannot exceed the remaining time.
+	tc = NewTimeControl(pos, false)
+	tc.WTime = time.Second
+	tc.MinThinkingTime = 2 * time.Second
+	tc.Start(false)
+	if tc.searchTime >= time.Second {
+		t.Errorf("got search time %v, wanted less than 1s", tc.searchTime)
+	}
+}
+
+func TestDeterministicTimeControl(t *testing.T) {
+	pos, _ := PositionFromFEN(FENStartPos)
+	tc := NewDeadlineTimeControl(pos, time.Second)
+	tc.Overhead = 0
+	tc.Deterministic = true
+	tc.Start(false)
+	if want := uint64(DeterministicNPS + 1); tc.Nodes != want {
+		t.Errorf("got %d nodes, wanted %d", tc.Nodes, want)
+	}
+
+	// A smaller node limit is kept.
+	tc.Nodes = 1000
+	tc.Start(false)
+	if tc.Nodes != 1000 {
+		t.Errorf("got %d nodes, wanted 1000", tc.Nodes)
+	}
+
+	// Without a time limit only the node limit applies.
+	tc = NewTimeControl(pos, false)
+	tc.Deterministic = true
+	tc.Start(false)
+	if tc.Nodes != 0 {
+		t.Errorf("got %d nodes, wanted no limit", tc.Nodes)
+	}
+
+	// The clock is ignored.
+	tc = NewDeadlineTimeControl(pos, time.Nanosecond)
+	tc.Deterministic = true
+	tc.Start(false)
+	tc.NextDepth(5)
+	if tc.Stopped() {
+		t.Errorf("deterministic time control was stopped by the clock")
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// trace.go implements recording of the search tree for debugging.
+//
+// Tracing is disabled when Engine.Tracer is nil. In that case the
+// search pays only for a nil check per node.
+
+package engine
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	. "bitbucket.org/zurichess/board"
+)
+
+// TraceFormat is the output format of a Tracer.
+type TraceFormat int
+
+const (
+	// TraceJSON writes one JSON object (a TraceNode) per line.
+	TraceJSON TraceFormat = iota
+	// TraceDot writes a Graphviz graph.
+	TraceDot
+)
+
+// Reasons why a node returned without a full search.
+const (
+	traceNone        = ""
+	traceEndPosition = "end"         // game ended (mate, stalemate, draw)
+	traceMatePruning = "mate"        // mate distance pruning
+	traceHash        = "hash"        // transposition table cut-off
+	traceNullMove    = "nullmove"    // null move pruning
+	traceRazoring    = "razoring"    // razoring
+	traceStandPat    = "standpat"    // quiescence stand pat
+	traceCutOff      = "cutoff"      // beta cut-off
+	traceStopped     = "stopped"     // search was stopped
+	traceFutility    = "futility"    // futility or history leaf pruning
+	traceLMR         = "lmr"         // late move reduction
+	traceLMRResearch = "lmrresearch" // late move reduction failed and the move was searched again
+	traceExtension   = "extension"   // check extension
+)
+
+// TraceNode is a node in the search tree.
+type TraceNode struct {
+	ID     int    `json:"id"`               // node id, increasing in search order
+	Parent int    `json:"parent"`           // parent's id, or -1 for root nodes
+	Ply    int32  `json:"ply"`              // distance from root
+	Depth  int32  `json:"depth"`            // remaining depth
+	Alpha  int32  `json:"alpha"`            // lower bound
+	Beta   int32  `json:"beta"`             // upper bound
+	Move   string `json:"move,omitempty"`   // move leading to this node in UCI format
+	Score  int32  `json:"score"`            // score returned from current player's POV
+	Reason string `json:"reason,omitempty"` // why the node was pruned, reduced or cut
+	Hash   bool   `json:"hash,omitempty"`   // true if the position was found in the transposition table
+	QS     bool   `json:"qs,omitempty"`     // true for quiescence nodes
+}
+
+// Tracer records the search tree to a writer.
+//
+// Nodes are written when they are left, so children are written
+// before their parents. A Tracer is not safe for concurrent use.
+type Tracer struct {
+	MaxPly   int32 // do not record nodes deeper than MaxPly
+	MaxNodes int   // maximum number of nodes recorded per search
+
+	w      *bufio.Writer
+	format TraceFormat
+	err    error // first write error
+
+	nextID int         // id of the next node
+	nodes  int         // number of nodes recorded in current search
+	stack  []TraceNode // open nodes; skipped nodes have ID -1
+	reason string      // reason for the node about to be entered or left
+}
+
+// NewTracer returns a new tracer writing to w in the given format.
+// By default MaxPly is 64 and MaxNodes is 1000000.
+func NewTracer(w io.Writer, format TraceFormat) *Tracer {
+	t := &Tracer{
+		MaxPly:   64,
+		MaxNodes: 1000000,
+		w:        bufio.NewWriter(w),
+		format:   format,
+	}
+	if format == TraceDot {
+		t.printf("digraph search {\n")
+		t.printf("\tnode [shape=box, fontname=monospace];\n")
+	}
+	return t
+}
+
+// Close flushes the remaining nodes and returns the first error encountered.
+// The underlying writer is not closed.
+func (t *Tracer) Close() error {
+	if t.format == TraceDot {
+		t.printf("}\n")
+	}
+	if err := t.w.Flush(); t.err == nil {
+		t.err = err
+	}
+	return t.err
+}
+
+func (t *Tracer) printf(format string, args ...interface{}) {
+	if t.err == nil {
+		_, t.err = fmt.Fprintf(t.w, format, args...)
+	}
+}
+
+// beginSearch resets the per search limits.
+func (t *Tracer) beginSearch() {
+	t.nodes = 0
+	t.stack = t.stack[:0]
+	t.reason = traceNone
+}
+
+// endSearch flushes the nodes recorded so far.
+func (t *Tracer) endSearch() {
+	if err := t.w.Flush(); t.err == nil {
+		t.err = err
+	}
+}
+
+// enter opens a new node.
+func (t *Tracer) enter(pos *Position, ply, α, β, depth int32, qs bool) {
+	parent := -1
+	if n := len(t.stack); n > 0 {
+		parent = t.stack[n-1].ID
+	}
+	if ply > t.MaxPly || t.nodes >= t.MaxNodes || ply > 0 && parent == -1 {
+		// Skip this node and all its children.
+		t.stack = append(t.stack, TraceNode{ID: -1})
+		t.reason = traceNone
+		return
+	}
+
+	node := TraceNode{
+		ID:     t.nextID,
+		Parent: parent,
+		Ply:    ply,
+		Depth:  depth,
+		Alpha:  α,
+		Beta:   β,
+		Reason: t.reason,
+		QS:     qs,
+	}
+	if ply > 0 {
+		node.Move = traceMove(pos.LastMove())
+	}
+	t.nextID++
+	t.nodes++
+	t.stack = append(t.stack, node)
+	t.reason = traceNone
+}
+
+// leave closes the current node and writes it.
+func (t *Tracer) leave(score int32) {
+	n := len(t.stack) - 1
+	node := t.stack[n]
+	t.stack = t.stack[:n]
+	if node.ID == -1 {
+		t.reason = traceNone
+		return
+	}
+
+	node.Score = score
+	if t.reason != traceNone {
+		// Reason set while leaving overrides the reason set by the parent.
+		node.Reason = t.reason
+	}
+	t.reason = traceNone
+
+	switch t.format {
+	case TraceJSON:
+		if t.err == nil {
+			var b []byte
+			b, t.err = json.Marshal(&node)
+			t.printf("%s\n", b)
+		}
+	case TraceDot:
+		label := fmt.Sprintf("%s d=%d [%d,%d] %d", node.Move, node.Depth, node.Alpha, node.Beta, node.Score)
+		if node.Reason != traceNone {
+			label += " " + node.Reason
+		}
+		if node.Hash {
+			label += " tt"
+		}
+		style := ""
+		if node.QS {
+			style = ", style=dashed"
+		}
+		t.printf("\tn%d [label=%q%s];\n", node.ID, label, style)
+		if node.Parent != -1 {
+			t.printf("\tn%d -> n%d;\n", node.Parent, node.ID)
+		}
+	}
+}
+
+// hashHit marks the current node as found in the transposition table.
+func (t *Tracer) hashHit() {
+	if n := len(t.stack); n > 0 {
+		t.stack[n-1].Hash = true
+	}
+}
+
+// traceMove formats m for tracing.
+func traceMove(m Move) string {
+	if m == NullMove {
+		return "0000"
+	}
+	return m.UCI()
+}
+
+// trace sets the reason for the next node entered or left.
+func (eng *Engine) trace(reason string) {
+	if eng.Tracer != nil {
+		eng.Tracer.reason = reason
+	}
+}
+
+// tracePruned records a move that was pruned without being searched.
+func (eng *Engine) tracePruned(move Move, α, β, depth int32, reason string) {
+	if eng.Tracer != nil {
+		eng.Position.DoMove(move)
+		eng.Tracer.reason = reason
+		eng.Tracer.enter(eng.Position, eng.ply(), -β, -α, depth-1, false)
+		eng.Tracer.leave(-α)
+		eng.Position.UndoMove()
+	}
+}
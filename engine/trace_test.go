@@ -0,0 +1,98 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	. "bitbucket.org/zurichess/board"
+	. "bitbucket.org/zurichess/zurichess/internal/testdata"
+)
+
+func traceSearch(t *testing.T, fen string, format TraceFormat, maxPly int32, maxNodes int) *bytes.Buffer {
+	pos, _ := PositionFromFEN(fen)
+	buf := &bytes.Buffer{}
+	eng := NewEngine(pos, nil, Options{})
+	eng.Tracer = NewTracer(buf, format)
+	eng.Tracer.MaxPly = maxPly
+	eng.Tracer.MaxNodes = maxNodes
+
+	GlobalHashTable.Clear()
+	tc := NewFixedDepthTimeControl(pos, 3)
+	tc.Start(false)
+	eng.Play(tc)
+	if err := eng.Tracer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf
+}
+
+func TestTraceJSON(t *testing.T) {
+	for _, fen := range TestFENs[:4] {
+		buf := traceSearch(t, fen, TraceJSON, 3, 100000)
+
+		nodes := make(map[int]TraceNode)
+		scan := bufio.NewScanner(buf)
+		for scan.Scan() {
+			var node TraceNode
+			if err := json.Unmarshal(scan.Bytes(), &node); err != nil {
+				t.Fatalf("%s: cannot parse %q: %v", fen, scan.Text(), err)
+			}
+			if _, has := nodes[node.ID]; has {
+				t.Fatalf("%s: duplicate node %d", fen, node.ID)
+			}
+			nodes[node.ID] = node
+		}
+		if len(nodes) == 0 {
+			t.Fatalf("%s: no nodes traced", fen)
+		}
+
+		for _, node := range nodes {
+			if node.Ply > 3 {
+				t.Errorf("%s: node %d has ply %d, expected at most 3", fen, node.ID, node.Ply)
+			}
+			if node.Parent == -1 {
+				if node.Ply != 0 {
+					t.Errorf("%s: root node %d has ply %d", fen, node.ID, node.Ply)
+				}
+				continue
+			}
+			parent, has := nodes[node.Parent]
+			if !has {
+				t.Errorf("%s: node %d has missing parent %d", fen, node.ID, node.Parent)
+				continue
+			}
+			if node.QS && parent.Ply == node.Ply {
+				// Quiescence search started from the same position.
+			} else if parent.Ply+1 != node.Ply {
+				t.Errorf("%s: node %d has ply %d, parent has ply %d", fen, node.ID, node.Ply, parent.Ply)
+			}
+			if node.Ply > 0 && node.Move == "" {
+				t.Errorf("%s: node %d has no move", fen, node.ID)
+			}
+		}
+	}
+}
+
+func TestTraceMaxNodes(t *testing.T) {
+	buf := traceSearch(t, FENStartPos, TraceJSON, 64, 10)
+	if n := strings.Count(buf.String(), "\n"); n > 10 {
+		t.Errorf("expected at most 10 nodes, got %d", n)
+	}
+}
+
+func TestTraceDot(t *testing.T) {
+	s := traceSearch(t, FENStartPos, TraceDot, 2, 1000).String()
+	if !strings.HasPrefix(s, "digraph search {\n") || !strings.HasSuffix(s, "}\n") {
+		t.Errorf("expected a digraph, got %q", s)
+	}
+	if !strings.Contains(s, " -> ") {
+		t.Errorf("expected edges in graph")
+	}
+}
@@ -37,4 +37,87 @@ var Weights = [...]Score{
 	{M: 9455, E: 33952}, {M: -24, E: -176}, {M: 0, E: 33}, {M: -1108, E: 10140}, {M: -7291, E: 5046}, {M: -2197, E: -4209}, {M: 136, E: -12279}, {M: 3, E: -13911},
 	{M: -28, E: -14428}, {M: -50, E: -12908}, {M: 147, E: -85}, {M: 4548, E: 12736}, {M: -43, E: 7638}, {M: 151, E: 1756}, {M: -2061, E: 273}, {M: -285, E: -218},
 	{M: 1997, E: -64}, {M: 226, E: 60},
+	// King safety, threats, outposts and space, not tuned yet.
+	{M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0},
+	{M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0},
+	{M: 0, E: 0},
+	// Full piece square tables, initialized to the sum of the file and rank weights.
+	{M: -4849, E: -4841}, {M: -1242, E: -2844}, {M: -527, E: -855}, {M: 627, E: 725}, {M: 1443, E: 31}, {M: 1524, E: -1402}, {M: 242, E: -2800}, {M: -1652, E: -5434},
+	{M: -4305, E: -2060}, {M: -698, E: -63}, {M: 17, E: 1926}, {M: 1171, E: 3506}, {M: 1987, E: 2812}, {M: 2068, E: 1379}, {M: 786, E: -19}, {M: -1108, E: -2653},
+	{M: -2115, E: -388}, {M: 1492, E: 1609}, {M: 2207, E: 3598}, {M: 3361, E: 5178}, {M: 4177, E: 4484}, {M: 4258, E: 3051}, {M: 2976, E: 1653}, {M: 1082, E: -981},
+	{M: -128, E: 1346}, {M: 3479, E: 3343}, {M: 4194, E: 5332}, {M: 5348, E: 6912}, {M: 6164, E: 6218}, {M: 6245, E: 4785}, {M: 4963, E: 3387}, {M: 3069, E: 753},
+	{M: 1640, E: 1257}, {M: 5247, E: 3254}, {M: 5962, E: 5243}, {M: 7116, E: 6823}, {M: 7932, E: 6129}, {M: 8013, E: 4696}, {M: 6731, E: 3298}, {M: 4837, E: 664},
+	{M: 662, E: -1041}, {M: 4269, E: 956}, {M: 4984, E: 2945}, {M: 6138, E: 4525}, {M: 6954, E: 3831}, {M: 7035, E: 2398}, {M: 5753, E: 1000}, {M: 3859, E: -1634},
+	{M: -4472, E: -2475}, {M: -865, E: -478}, {M: -150, E: 1511}, {M: 1004, E: 3091}, {M: 1820, E: 2397}, {M: 1901, E: 964}, {M: 619, E: -434}, {M: -1275, E: -3068},
+	{M: -17806, E: -4078}, {M: -14199, E: -2081}, {M: -13484, E: -92}, {M: -12330, E: 1488}, {M: -11514, E: 794}, {M: -11433, E: -639}, {M: -12715, E: -2037}, {M: -14609, E: -4671},
+	{M: -4849, E: -4841}, {M: -1242, E: -2844}, {M: -527, E: -855}, {M: 627, E: 725}, {M: 1443, E: 31}, {M: 1524, E: -1402}, {M: 242, E: -2800}, {M: -1652, E: -5434},
+	{M: -4305, E: -2060}, {M: -698, E: -63}, {M: 17, E: 1926}, {M: 1171, E: 3506}, {M: 1987, E: 2812}, {M: 2068, E: 1379}, {M: 786, E: -19}, {M: -1108, E: -2653},
+	{M: -2115, E: -388}, {M: 1492, E: 1609}, {M: 2207, E: 3598}, {M: 3361, E: 5178}, {M: 4177, E: 4484}, {M: 4258, E: 3051}, {M: 2976, E: 1653}, {M: 1082, E: -981},
+	{M: -128, E: 1346}, {M: 3479, E: 3343}, {M: 4194, E: 5332}, {M: 5348, E: 6912}, {M: 6164, E: 6218}, {M: 6245, E: 4785}, {M: 4963, E: 3387}, {M: 3069, E: 753},
+	{M: 1640, E: 1257}, {M: 5247, E: 3254}, {M: 5962, E: 5243}, {M: 7116, E: 6823}, {M: 7932, E: 6129}, {M: 8013, E: 4696}, {M: 6731, E: 3298}, {M: 4837, E: 664},
+	{M: 662, E: -1041}, {M: 4269, E: 956}, {M: 4984, E: 2945}, {M: 6138, E: 4525}, {M: 6954, E: 3831}, {M: 7035, E: 2398}, {M: 5753, E: 1000}, {M: 3859, E: -1634},
+	{M: -4472, E: -2475}, {M: -865, E: -478}, {M: -150, E: 1511}, {M: 1004, E: 3091}, {M: 1820, E: 2397}, {M: 1901, E: 964}, {M: 619, E: -434}, {M: -1275, E: -3068},
+	{M: -17806, E: -4078}, {M: -14199, E: -2081}, {M: -13484, E: -92}, {M: -12330, E: 1488}, {M: -11514, E: 794}, {M: -11433, E: -639}, {M: -12715, E: -2037}, {M: -14609, E: -4671},
+	{M: -3301, E: -432}, {M: 729, E: -381}, {M: 859, E: -116}, {M: -988, E: 810}, {M: -262, E: 882}, {M: 48, E: 360}, {M: 3180, E: -1246}, {M: -1321, E: -325},
+	{M: -1905, E: -170}, {M: 2125, E: -119}, {M: 2255, E: 146}, {M: 408, E: 1072}, {M: 1134, E: 1144}, {M: 1444, E: 622}, {M: 4576, E: -984}, {M: 75, E: -63},
+	{M: -377, E: 651}, {M: 3653, E: 702}, {M: 3783, E: 967}, {M: 1936, E: 1893}, {M: 2662, E: 1965}, {M: 2972, E: 1443}, {M: 6104, E: -163}, {M: 1603, E: 758},
+	{M: -2394, E: 673}, {M: 1636, E: 724}, {M: 1766, E: 989}, {M: -81, E: 1915}, {M: 645, E: 1987}, {M: 955, E: 1465}, {M: 4087, E: -141}, {M: -414, E: 780},
+	{M: -2035, E: 280}, {M: 1995, E: 331}, {M: 2125, E: 596}, {M: 278, E: 1522}, {M: 1004, E: 1594}, {M: 1314, E: 1072}, {M: 4446, E: -534}, {M: -55, E: 387},
+	{M: -3105, E: -178}, {M: 925, E: -127}, {M: 1055, E: 138}, {M: -792, E: 1064}, {M: -66, E: 1136}, {M: 244, E: 614}, {M: 3376, E: -992}, {M: -1125, E: -71},
+	{M: -10887, E: 1007}, {M: -6857, E: 1058}, {M: -6727, E: 1323}, {M: -8574, E: 2249}, {M: -7848, E: 2321}, {M: -7538, E: 1799}, {M: -4406, E: 193}, {M: -8907, E: 1114},
+	{M: -10023, E: -271}, {M: -5993, E: -220}, {M: -5863, E: 45}, {M: -7710, E: 971}, {M: -6984, E: 1043}, {M: -6674, E: 521}, {M: -3542, E: -1085}, {M: -8043, E: -164},
+	{M: -3301, E: -432}, {M: 729, E: -381}, {M: 859, E: -116}, {M: -988, E: 810}, {M: -262, E: 882}, {M: 48, E: 360}, {M: 3180, E: -1246}, {M: -1321, E: -325},
+	{M: -1905, E: -170}, {M: 2125, E: -119}, {M: 2255, E: 146}, {M: 408, E: 1072}, {M: 1134, E: 1144}, {M: 1444, E: 622}, {M: 4576, E: -984}, {M: 75, E: -63},
+	{M: -377, E: 651}, {M: 3653, E: 702}, {M: 3783, E: 967}, {M: 1936, E: 1893}, {M: 2662, E: 1965}, {M: 2972, E: 1443}, {M: 6104, E: -163}, {M: 1603, E: 758},
+	{M: -2394, E: 673}, {M: 1636, E: 724}, {M: 1766, E: 989}, {M: -81, E: 1915}, {M: 645, E: 1987}, {M: 955, E: 1465}, {M: 4087, E: -141}, {M: -414, E: 780},
+	{M: -2035, E: 280}, {M: 1995, E: 331}, {M: 2125, E: 596}, {M: 278, E: 1522}, {M: 1004, E: 1594}, {M: 1314, E: 1072}, {M: 4446, E: -534}, {M: -55, E: 387},
+	{M: -3105, E: -178}, {M: 925, E: -127}, {M: 1055, E: 138}, {M: -792, E: 1064}, {M: -66, E: 1136}, {M: 244, E: 614}, {M: 3376, E: -992}, {M: -1125, E: -71},
+	{M: -10887, E: 1007}, {M: -6857, E: 1058}, {M: -6727, E: 1323}, {M: -8574, E: 2249}, {M: -7848, E: 2321}, {M: -7538, E: 1799}, {M: -4406, E: 193}, {M: -8907, E: 1114},
+	{M: -10023, E: -271}, {M: -5993, E: -220}, {M: -5863, E: 45}, {M: -7710, E: 971}, {M: -6984, E: 1043}, {M: -6674, E: 521}, {M: -3542, E: -1085}, {M: -8043, E: -164},
+	{M: 1274, E: -2099}, {M: 2021, E: -1891}, {M: 2578, E: -1618}, {M: 4257, E: -2466}, {M: 4639, E: -3281}, {M: 5946, E: -3522}, {M: 1283, E: -2286}, {M: 2246, E: -3702},
+	{M: -1528, E: -840}, {M: -781, E: -632}, {M: -224, E: -359}, {M: 1455, E: -1207}, {M: 1837, E: -2022}, {M: 3144, E: -2263}, {M: -1519, E: -1027}, {M: -556, E: -2443},
+	{M: -930, E: -806}, {M: -183, E: -598}, {M: 374, E: -325}, {M: 2053, E: -1173}, {M: 2435, E: -1988}, {M: 3742, E: -2229}, {M: -921, E: -993}, {M: 42, E: -2409},
+	{M: -1550, E: 449}, {M: -803, E: 657}, {M: -246, E: 930}, {M: 1433, E: 82}, {M: 1815, E: -733}, {M: 3122, E: -974}, {M: -1541, E: 262}, {M: -578, E: -1154},
+	{M: -926, E: 793}, {M: -179, E: 1001}, {M: 378, E: 1274}, {M: 2057, E: 426}, {M: 2439, E: -389}, {M: 3746, E: -630}, {M: -917, E: 606}, {M: 46, E: -810},
+	{M: -877, E: 986}, {M: -130, E: 1194}, {M: 427, E: 1467}, {M: 2106, E: 619}, {M: 2488, E: -196}, {M: 3795, E: -437}, {M: -868, E: 799}, {M: 95, E: -617},
+	{M: 325, E: 1705}, {M: 1072, E: 1913}, {M: 1629, E: 2186}, {M: 3308, E: 1338}, {M: 3690, E: 523}, {M: 4997, E: 282}, {M: 334, E: 1518}, {M: 1297, E: 102},
+	{M: -1765, E: 2349}, {M: -1018, E: 2557}, {M: -461, E: 2830}, {M: 1218, E: 1982}, {M: 1600, E: 1167}, {M: 2907, E: 926}, {M: -1756, E: 2162}, {M: -793, E: 746},
+	{M: 1274, E: -2099}, {M: 2021, E: -1891}, {M: 2578, E: -1618}, {M: 4257, E: -2466}, {M: 4639, E: -3281}, {M: 5946, E: -3522}, {M: 1283, E: -2286}, {M: 2246, E: -3702},
+	{M: -1528, E: -840}, {M: -781, E: -632}, {M: -224, E: -359}, {M: 1455, E: -1207}, {M: 1837, E: -2022}, {M: 3144, E: -2263}, {M: -1519, E: -1027}, {M: -556, E: -2443},
+	{M: -930, E: -806}, {M: -183, E: -598}, {M: 374, E: -325}, {M: 2053, E: -1173}, {M: 2435, E: -1988}, {M: 3742, E: -2229}, {M: -921, E: -993}, {M: 42, E: -2409},
+	{M: -1550, E: 449}, {M: -803, E: 657}, {M: -246, E: 930}, {M: 1433, E: 82}, {M: 1815, E: -733}, {M: 3122, E: -974}, {M: -1541, E: 262}, {M: -578, E: -1154},
+	{M: -926, E: 793}, {M: -179, E: 1001}, {M: 378, E: 1274}, {M: 2057, E: 426}, {M: 2439, E: -389}, {M: 3746, E: -630}, {M: -917, E: 606}, {M: 46, E: -810},
+	{M: -877, E: 986}, {M: -130, E: 1194}, {M: 427, E: 1467}, {M: 2106, E: 619}, {M: 2488, E: -196}, {M: 3795, E: -437}, {M: -868, E: 799}, {M: 95, E: -617},
+	{M: 325, E: 1705}, {M: 1072, E: 1913}, {M: 1629, E: 2186}, {M: 3308, E: 1338}, {M: 3690, E: 523}, {M: 4997, E: 282}, {M: 334, E: 1518}, {M: 1297, E: 102},
+	{M: -1765, E: 2349}, {M: -1018, E: 2557}, {M: -461, E: 2830}, {M: 1218, E: 1982}, {M: 1600, E: 1167}, {M: 2907, E: 926}, {M: -1756, E: 2162}, {M: -793, E: 746},
+	{M: 13665, E: -5717}, {M: 12692, E: -6849}, {M: 12538, E: -7323}, {M: 12989, E: -6994}, {M: 13252, E: -6699}, {M: 13786, E: -6799}, {M: 15650, E: -6719}, {M: 15251, E: -5017},
+	{M: 9889, E: -5809}, {M: 8916, E: -6941}, {M: 8762, E: -7415}, {M: 9213, E: -7086}, {M: 9476, E: -6791}, {M: 10010, E: -6891}, {M: 11874, E: -6811}, {M: 11475, E: -5109},
+	{M: 5729, E: -1857}, {M: 4756, E: -2989}, {M: 4602, E: -3463}, {M: 5053, E: -3134}, {M: 5316, E: -2839}, {M: 5850, E: -2939}, {M: 7714, E: -2859}, {M: 7315, E: -1157},
+	{M: 832, E: 1221}, {M: -141, E: 89}, {M: -295, E: -385}, {M: 156, E: -56}, {M: 419, E: 239}, {M: 953, E: 139}, {M: 2817, E: 219}, {M: 2418, E: 1921},
+	{M: -3050, E: 3398}, {M: -4023, E: 2266}, {M: -4177, E: 1792}, {M: -3726, E: 2121}, {M: -3463, E: 2416}, {M: -2929, E: 2316}, {M: -1065, E: 2396}, {M: -1464, E: 4098},
+	{M: -183, E: 971}, {M: -1156, E: -161}, {M: -1310, E: -635}, {M: -859, E: -306}, {M: -596, E: -11}, {M: -62, E: -111}, {M: 1802, E: -31}, {M: 1403, E: 1671},
+	{M: -6131, E: 4394}, {M: -7104, E: 3262}, {M: -7258, E: 2788}, {M: -6807, E: 3117}, {M: -6544, E: 3412}, {M: -6010, E: 3312}, {M: -4146, E: 3392}, {M: -4545, E: 5094},
+	{M: 454, E: 1990}, {M: -519, E: 858}, {M: -673, E: 384}, {M: -222, E: 713}, {M: 41, E: 1008}, {M: 575, E: 908}, {M: 2439, E: 988}, {M: 2040, E: 2690},
+	{M: 13665, E: -5717}, {M: 12692, E: -6849}, {M: 12538, E: -7323}, {M: 12989, E: -6994}, {M: 13252, E: -6699}, {M: 13786, E: -6799}, {M: 15650, E: -6719}, {M: 15251, E: -5017},
+	{M: 9889, E: -5809}, {M: 8916, E: -6941}, {M: 8762, E: -7415}, {M: 9213, E: -7086}, {M: 9476, E: -6791}, {M: 10010, E: -6891}, {M: 11874, E: -6811}, {M: 11475, E: -5109},
+	{M: 5729, E: -1857}, {M: 4756, E: -2989}, {M: 4602, E: -3463}, {M: 5053, E: -3134}, {M: 5316, E: -2839}, {M: 5850, E: -2939}, {M: 7714, E: -2859}, {M: 7315, E: -1157},
+	{M: 832, E: 1221}, {M: -141, E: 89}, {M: -295, E: -385}, {M: 156, E: -56}, {M: 419, E: 239}, {M: 953, E: 139}, {M: 2817, E: 219}, {M: 2418, E: 1921},
+	{M: -3050, E: 3398}, {M: -4023, E: 2266}, {M: -4177, E: 1792}, {M: -3726, E: 2121}, {M: -3463, E: 2416}, {M: -2929, E: 2316}, {M: -1065, E: 2396}, {M: -1464, E: 4098},
+	{M: -183, E: 971}, {M: -1156, E: -161}, {M: -1310, E: -635}, {M: -859, E: -306}, {M: -596, E: -11}, {M: -62, E: -111}, {M: 1802, E: -31}, {M: 1403, E: 1671},
+	{M: -6131, E: 4394}, {M: -7104, E: 3262}, {M: -7258, E: 2788}, {M: -6807, E: 3117}, {M: -6544, E: 3412}, {M: -6010, E: 3312}, {M: -4146, E: 3392}, {M: -4545, E: 5094},
+	{M: 454, E: 1990}, {M: -519, E: 858}, {M: -673, E: 384}, {M: -222, E: 713}, {M: 41, E: 1008}, {M: 575, E: 908}, {M: 2439, E: 988}, {M: 2040, E: 2690},
+	{M: -4781, E: -7026}, {M: -445, E: -4494}, {M: -3032, E: -2370}, {M: -12611, E: -422}, {M: -4118, E: -1575}, {M: -7664, E: -40}, {M: 116, E: -2683}, {M: -2226, E: -5724},
+	{M: -1188, E: -5301}, {M: 3148, E: -2769}, {M: 561, E: -645}, {M: -9018, E: 1303}, {M: -525, E: 150}, {M: -4071, E: 1685}, {M: 3709, E: -958}, {M: 1367, E: -3999},
+	{M: -1172, E: -4301}, {M: 3164, E: -1769}, {M: 577, E: 355}, {M: -9002, E: 2303}, {M: -509, E: 1150}, {M: -4055, E: 2685}, {M: 3725, E: 42}, {M: 1383, E: -2999},
+	{M: -4533, E: -4373}, {M: -197, E: -1841}, {M: -2784, E: 283}, {M: -12363, E: 2231}, {M: -3870, E: 1078}, {M: -7416, E: 2613}, {M: 364, E: -30}, {M: -1978, E: -3071},
+	{M: -1216, E: -4011}, {M: 3120, E: -1479}, {M: 533, E: 645}, {M: -9046, E: 2593}, {M: -553, E: 1440}, {M: -4099, E: 2975}, {M: 3681, E: 332}, {M: 1339, E: -2709},
+	{M: 5003, E: -4381}, {M: 9339, E: -1849}, {M: 6752, E: 275}, {M: -2827, E: 2223}, {M: 5666, E: 1070}, {M: 2120, E: 2605}, {M: 9900, E: -38}, {M: 7558, E: -3079},
+	{M: -1161, E: -4269}, {M: 3175, E: -1737}, {M: 588, E: 387}, {M: -8991, E: 2335}, {M: -498, E: 1182}, {M: -4044, E: 2717}, {M: 3736, E: 74}, {M: 1394, E: -2967},
+	{M: -1220, E: -6392}, {M: 3116, E: -3860}, {M: 529, E: -1736}, {M: -9050, E: 212}, {M: -557, E: -941}, {M: -4103, E: 594}, {M: 3677, E: -2049}, {M: 1335, E: -5090},
+	// Material imbalance, not tuned yet.
+	{M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0},
+	{M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0},
+	{M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0},
+	{M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0},
+	{M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0}, {M: 0, E: 0},
 }
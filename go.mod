@@ -2,4 +2,10 @@ module bitbucket.org/zurichess/zurichess
 
 go 1.14
 
-require bitbucket.org/zurichess/board v1.0.0
+require (
+	bitbucket.org/zurichess/board v1.0.0
+	github.com/golang/protobuf v1.4.3
+	golang.org/x/net v0.0.0-20200226121028-0de0cce0169b
+	google.golang.org/grpc v1.29.1
+	google.golang.org/protobuf v1.25.0
+)
@@ -0,0 +1,92 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package service implements the parts shared by the analysis
+// servers: the engine pool, the limits of the engine options and
+// the conversion of the search results.
+package service
+
+import (
+	"context"
+
+	. "bitbucket.org/zurichess/board"
+	"bitbucket.org/zurichess/zurichess/engine"
+)
+
+const (
+	MaxMultiPV       = 16 // maximum number of principal variations
+	MaxHandicapLevel = 20 // maximum handicap level
+)
+
+// MateIn returns the number of moves to mate for the search score s,
+// negative if the side to move is mated. ok is false if s is not a mate score.
+func MateIn(s int32) (mate int32, ok bool) {
+	if s > engine.KnownWinScore {
+		return (engine.MateScore - s + 1) / 2, true
+	}
+	if s < engine.KnownLossScore {
+		return (engine.MatedScore - s) / 2, true
+	}
+	return 0, false
+}
+
+// MovesToUCI converts moves to UCI format.
+func MovesToUCI(moves []Move) []string {
+	s := make([]string, len(moves))
+	for i, m := range moves {
+		s[i] = m.UCI()
+	}
+	return s
+}
+
+// Pool is a pool of engines, each with its own transposition table.
+//
+// Requests are served by a fixed number of engines, so at most
+// that many requests are handled in parallel and the others wait
+// for an engine to become available.
+type Pool struct {
+	engines chan *engine.Engine
+	hashMB  int // default size of the transposition tables
+	size    int // number of entries of a table of hashMB
+}
+
+// NewPool returns a pool of n engines with hashMB transposition tables.
+func NewPool(n, hashMB int) *Pool {
+	p := &Pool{
+		engines: make(chan *engine.Engine, n),
+		hashMB:  hashMB,
+	}
+	for i := 0; i < n; i++ {
+		eng := engine.NewEngine(nil, nil, engine.Options{})
+		eng.HashTable = engine.NewHashTable(hashMB)
+		p.size = eng.HashTable.Size()
+		p.engines <- eng
+	}
+	return p
+}
+
+// HashMB returns the default size of the transposition tables.
+func (p *Pool) HashMB() int {
+	return p.hashMB
+}
+
+// Get returns an idle engine. Blocks until an engine is available or ctx is done.
+func (p *Pool) Get(ctx context.Context) (*engine.Engine, error) {
+	select {
+	case eng := <-p.engines:
+		return eng, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Put returns eng to the pool. If the transposition table of eng
+// was resized, it is replaced by a table of the default size.
+func (p *Pool) Put(eng *engine.Engine) {
+	eng.Log = &engine.NulLogger{}
+	if eng.HashTable.Size() != p.size {
+		eng.HashTable = engine.NewHashTable(p.hashMB)
+	}
+	p.engines <- eng
+}
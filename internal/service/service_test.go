@@ -0,0 +1,58 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bitbucket.org/zurichess/zurichess/engine"
+)
+
+func TestMateIn(t *testing.T) {
+	data := []struct {
+		score int32
+		mate  int32
+		ok    bool
+	}{
+		{engine.MateScore - 1, 1, true},
+		{engine.MateScore - 3, 2, true},
+		{engine.MatedScore + 2, -1, true},
+		{150, 0, false},
+		{-150, 0, false},
+	}
+	for _, d := range data {
+		if mate, ok := MateIn(d.score); mate != d.mate || ok != d.ok {
+			t.Errorf("MateIn(%d) = %d, %v; wanted %d, %v", d.score, mate, ok, d.mate, d.ok)
+		}
+	}
+}
+
+func TestPool(t *testing.T) {
+	p := NewPool(1, 2)
+	eng, err := p.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The only engine is in use.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.Get(ctx); err != context.DeadlineExceeded {
+		t.Errorf("got error %v, wanted deadline exceeded", err)
+	}
+
+	// A resized table is restored to the default size.
+	want := eng.HashTable.Size()
+	eng.HashTable = engine.NewHashTable(1)
+	p.Put(eng)
+	if eng, err = p.Get(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := eng.HashTable.Size(); got != want {
+		t.Errorf("got %d hash entries, wanted %d", got, want)
+	}
+}
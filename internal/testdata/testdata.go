@@ -86,6 +86,34 @@ var (
 		{"rnbqkr2/pp1pbN1p/8/3p4/2B5/2p5/P4PPP/R3R1K1 w q - 0 1", "f7d6"},
 	}
 
+	// Mate in two and mate in three tests.
+	// Positions from TestGames and their neighbours with a unique key move.
+	MateIn2 = []struct {
+		FEN string
+		BM  string
+	}{
+		{"r4rk1/pppb1ppp/2n1p3/3p4/5PBq/1PP5/P1QPPn1P/RNB1K1NR b - - 4 12", "f2d3"},
+		{"rnbqkbnr/pppp1ppp/8/4p3/8/5P1P/PPPPP1P1/RNBQKBNR b KQkq - 0 2", "d8h4"},
+		{"r1bqkbnr/ppp2ppp/2n5/3pp3/8/5P1P/PPPPP1P1/RNBQKBNR b KQkq - 0 4", "d8h4"},
+		{"r6k/2PR3p/7P/p3p1P1/5n2/2P5/R4P2/4K1N1 w - - 3 43", "d7d8"},
+		{"7k/2PR2rp/7P/R3p1P1/5n2/2P5/5P2/4K1N1 w - - 1 44", "h6g7"},
+		{"2QRr2k/7p/6nP/R3p1P1/8/2P5/3K1P2/6N1 w - - 1 47", "d8e8"},
+		{"2r5/p6p/4p1p1/2p3R1/NrPpbP1P/1P1k4/P7/2K1R3 w - - 1 33", "g5g3"},
+		{"2r5/p6p/4p1p1/2p5/N1Pp1PRP/1r1k4/P7/2K1R3 w - - 0 34", "a2b3"},
+	}
+
+	MateIn3 = []struct {
+		FEN string
+		BM  string
+	}{
+		{"r4rk1/pppb1ppp/2n1p3/3p4/4nPBq/1PP5/P1QPP2P/RNBK2NR b - - 2 11", "e4f2"},
+		{"6rk/2PR3p/7P/R3p1P1/8/2P5/4nP2/4K1N1 w - - 1 44", "a5a8"},
+		{"4r2k/2PR3p/7P/R3p1P1/8/2P5/3K1Pn1/6N1 w - - 3 45", "a5a8"},
+		{"3R2rk/2P4p/7P/R2np1P1/8/2P5/3K1P2/6N1 w - - 5 46", "a5d5"},
+		{"3R2rk/2P4p/4n2P/R3p1P1/8/2P5/3K1P2/6N1 w - - 5 46", "a5a8"},
+		{"2r5/p6p/4p1p1/2p2bR1/N1Pp1P1P/1r1k4/P7/2K1R3 w - - 0 33", "a2b3"},
+	}
+
 	// Few test positions from past bugs.
 	TestFENs = []string{
 		// Initial position
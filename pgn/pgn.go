@@ -0,0 +1,102 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package pgn reads and writes games in Portable Game Notation.
+//
+// The format is described at
+// http://www.saremba.de/chessgml/standards/pgn/pgn-complete.htm.
+//
+// Moves are stored as board.Move and are converted to and from
+// Standard Algebraic Notation using engine.MoveToSAN and engine.SANToMove.
+package pgn
+
+import (
+	. "bitbucket.org/zurichess/board"
+)
+
+// Results of a game.
+const (
+	WhiteWins  = "1-0"
+	BlackWins  = "0-1"
+	Draw       = "1/2-1/2"
+	NoResult   = "*"
+	fenTag     = "FEN"
+	setUpTag   = "SetUp"
+	resultTag  = "Result"
+	maxLineLen = 79
+)
+
+// Tag is a tag pair, e.g. [Event "World Championship"].
+type Tag struct {
+	Name  string
+	Value string
+}
+
+// Node is a move in a game together with its annotations.
+type Node struct {
+	Move       Move
+	NAGs       []int     // Numeric Annotation Glyphs, e.g. 2 for ?
+	Before     string    // comment before the move, only at the start of a line
+	Comment    string    // comment after the move
+	Variations [][]*Node // alternatives to Move played from the same position
+}
+
+// Game is a game with its tags and moves.
+type Game struct {
+	Tags   []Tag   // tags in the order read
+	Moves  []*Node // main line
+	Result string  // one of WhiteWins, BlackWins, Draw or NoResult
+}
+
+// NewGame returns a new game with moves played from pos.
+// If pos is nil the game starts from the start position.
+func NewGame(pos *Position, moves []Move) *Game {
+	g := &Game{Result: NoResult}
+	if pos != nil && pos.String() != FENStartPos {
+		g.SetTag(setUpTag, "1")
+		g.SetTag(fenTag, pos.String())
+	}
+	for _, m := range moves {
+		g.Moves = append(g.Moves, &Node{Move: m})
+	}
+	return g
+}
+
+// Tag returns the value of the tag name or the empty string if the tag is missing.
+func (g *Game) Tag(name string) string {
+	for _, t := range g.Tags {
+		if t.Name == name {
+			return t.Value
+		}
+	}
+	return ""
+}
+
+// SetTag sets the value of the tag name, adding the tag if missing.
+func (g *Game) SetTag(name, value string) {
+	for i := range g.Tags {
+		if g.Tags[i].Name == name {
+			g.Tags[i].Value = value
+			return
+		}
+	}
+	g.Tags = append(g.Tags, Tag{name, value})
+}
+
+// Position returns the starting position of the game given by the FEN tag.
+func (g *Game) Position() (*Position, error) {
+	if fen := g.Tag(fenTag); fen != "" {
+		return PositionFromFEN(fen)
+	}
+	return PositionFromFEN(FENStartPos)
+}
+
+// MainLine returns the moves of the main line.
+func (g *Game) MainLine() []Move {
+	moves := make([]Move, len(g.Moves))
+	for i, n := range g.Moves {
+		moves[i] = n.Move
+	}
+	return moves
+}
@@ -0,0 +1,430 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pgn
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	. "bitbucket.org/zurichess/board"
+	"bitbucket.org/zurichess/zurichess/engine"
+)
+
+type tokenKind int
+
+const (
+	tokenSymbol         tokenKind = iota // moves, move numbers, results and tag names
+	tokenString                          // tag values
+	tokenComment                         // {comment} or ;comment
+	tokenNAG                             // $1 or suffix annotations
+	tokenOpenTag                         // [
+	tokenCloseTag                        // ]
+	tokenOpenVariation                   // (
+	tokenCloseVariation                  // )
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// suffixToNAG maps the suffix annotations to Numeric Annotation Glyphs.
+var suffixToNAG = map[string]int{"!": 1, "?": 2, "!!": 3, "??": 4, "!?": 5, "?!": 6}
+
+// Reader reads games from a PGN stream.
+type Reader struct {
+	r      *bufio.Reader
+	line   int    // current line, 1-based
+	bol    bool   // true if at the beginning of a line
+	bolTag bool   // true if the last [ was at the beginning of a line
+	peeked *token // token read but not consumed
+	err    error  // first error returned by the underlying reader, except io.EOF
+}
+
+// NewReader returns a new reader reading games from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r), line: 1, bol: true}
+}
+
+// Read reads the next game. Returns io.EOF if there are no more games.
+// If the game cannot be parsed the rest of the game is skipped so that
+// the following games can still be read.
+func (r *Reader) Read() (*Game, error) {
+	g, err := r.read()
+	if err != nil && err != io.EOF {
+		err = fmt.Errorf("pgn: line %d: %v", r.line, err)
+		r.skipGame()
+	}
+	return g, err
+}
+
+func (r *Reader) read() (*Game, error) {
+	// Skip the comments between games.
+	tok, err := r.next()
+	for err == nil && tok.kind == tokenComment {
+		tok, err = r.next()
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.unread(tok)
+
+	// Read the tag pairs.
+	g := &Game{}
+	for tok.kind == tokenOpenTag {
+		r.next()
+		name, err := r.expect(tokenSymbol)
+		if err != nil {
+			return nil, err
+		}
+		value, err := r.expect(tokenString)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := r.expect(tokenCloseTag); err != nil {
+			return nil, err
+		}
+		g.Tags = append(g.Tags, Tag{name, value})
+
+		if tok, err = r.next(); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		r.unread(tok)
+	}
+
+	// Read the movetext.
+	pos, err := g.Position()
+	if err != nil {
+		return nil, err
+	}
+	if g.Result, err = r.readLine(pos, &g.Moves, false); err != nil {
+		return nil, err
+	}
+	if g.Result == "" {
+		if g.Result = g.Tag(resultTag); g.Result == "" {
+			g.Result = NoResult
+		}
+	}
+	return g, nil
+}
+
+// readLine reads the moves of a line played from pos.
+// pos is advanced by the moves read.
+// Returns the game result if the line is terminated by one.
+func (r *Reader) readLine(pos *Position, line *[]*Node, variation bool) (string, error) {
+	var last *Node
+	before := ""
+	for {
+		tok, err := r.next()
+		if err == io.EOF && !variation {
+			return "", nil
+		} else if err == io.EOF {
+			return "", errors.New("unterminated variation")
+		} else if err != nil {
+			return "", err
+		}
+
+		switch tok.kind {
+		case tokenComment:
+			if last == nil {
+				before = joinComments(before, tok.text)
+			} else {
+				last.Comment = joinComments(last.Comment, tok.text)
+			}
+
+		case tokenNAG:
+			if last == nil {
+				return "", fmt.Errorf("annotation %s before move", tok.text)
+			}
+			nag, err := parseNAG(tok.text)
+			if err != nil {
+				return "", err
+			}
+			last.NAGs = append(last.NAGs, nag)
+
+		case tokenOpenVariation:
+			if last == nil {
+				return "", errors.New("variation before move")
+			}
+			pos.UndoMove()
+			var v []*Node
+			if _, err := r.readLine(pos, &v, true); err != nil {
+				return "", err
+			}
+			for range v {
+				pos.UndoMove()
+			}
+			pos.DoMove(last.Move)
+			if len(v) != 0 {
+				last.Variations = append(last.Variations, v)
+			}
+
+		case tokenCloseVariation:
+			if !variation {
+				return "", errors.New("unexpected )")
+			}
+			return "", nil
+
+		case tokenOpenTag:
+			if variation {
+				return "", errors.New("unterminated variation")
+			}
+			// A new game starts without a result.
+			r.unread(tok)
+			return "", nil
+
+		case tokenSymbol:
+			if isResult(tok.text) {
+				if variation {
+					return "", errors.New("unterminated variation")
+				}
+				return tok.text, nil
+			}
+			m, err := engine.SANToMove(pos, tok.text)
+			if err != nil {
+				return "", err
+			}
+			last = &Node{Move: m, Before: before}
+			before = ""
+			*line = append(*line, last)
+			pos.DoMove(m)
+
+		default:
+			return "", fmt.Errorf("unexpected %q", tok.text)
+		}
+	}
+}
+
+// skipGame skips the tokens until the end of the current game.
+func (r *Reader) skipGame() {
+	for {
+		tok, err := r.next()
+		if err == io.EOF || r.err != nil {
+			return
+		} else if err != nil {
+			continue
+		}
+		if tok.kind == tokenSymbol && isResult(tok.text) {
+			return
+		}
+		if tok.kind == tokenOpenTag && r.bolTag {
+			r.unread(tok)
+			return
+		}
+	}
+}
+
+// expect reads the next token and fails if it is not of kind.
+func (r *Reader) expect(kind tokenKind) (string, error) {
+	tok, err := r.next()
+	if err == io.EOF {
+		return "", io.ErrUnexpectedEOF
+	} else if err != nil {
+		return "", err
+	}
+	if tok.kind != kind {
+		return "", fmt.Errorf("unexpected %q", tok.text)
+	}
+	return tok.text, nil
+}
+
+// unread pushes back tok to be returned by the next call to next.
+func (r *Reader) unread(tok token) {
+	r.peeked = &tok
+}
+
+// readByte reads the next byte keeping track of the current line.
+func (r *Reader) readByte() (byte, error) {
+	c, err := r.r.ReadByte()
+	if err != nil {
+		if err != io.EOF && r.err == nil {
+			r.err = err
+		}
+		return 0, err
+	}
+	r.bol = c == '\n'
+	if c == '\n' {
+		r.line++
+	}
+	return c, nil
+}
+
+// unreadByte unreads c which must be the last byte read.
+func (r *Reader) unreadByte(c byte) {
+	r.r.UnreadByte()
+	if c == '\n' {
+		r.line--
+		r.bol = true
+	}
+}
+
+// next returns the next token.
+func (r *Reader) next() (token, error) {
+	if r.peeked != nil {
+		tok := *r.peeked
+		r.peeked = nil
+		return tok, nil
+	}
+
+	for {
+		bol := r.bol
+		c, err := r.readByte()
+		if err != nil {
+			return token{}, err
+		}
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			// Skip white spaces.
+		case c == '%' && bol:
+			// Skip escaped lines.
+			if _, err := r.readUntil('\n'); err != nil {
+				return token{}, err
+			}
+		case c == '[':
+			r.bolTag = bol
+			return token{tokenOpenTag, "["}, nil
+		case c == ']':
+			return token{tokenCloseTag, "]"}, nil
+		case c == '(':
+			return token{tokenOpenVariation, "("}, nil
+		case c == ')':
+			return token{tokenCloseVariation, ")"}, nil
+		case c == '{':
+			s, err := r.readUntil('}')
+			if err == io.EOF {
+				return token{}, errors.New("unterminated comment")
+			}
+			return token{tokenComment, strings.Join(strings.Fields(s), " ")}, err
+		case c == ';':
+			s, err := r.readUntil('\n')
+			if err != nil && err != io.EOF {
+				return token{}, err
+			}
+			return token{tokenComment, strings.TrimSpace(s)}, nil
+		case c == '"':
+			s, err := r.readString()
+			return token{tokenString, s}, err
+		case c == '$':
+			s, err := r.readSymbol()
+			return token{tokenNAG, "$" + s}, err
+		case !isSymbolByte(c):
+			return token{}, fmt.Errorf("unexpected %q", c)
+		default:
+			r.unreadByte(c)
+			s, err := r.readSymbol()
+			if err != nil {
+				return token{}, err
+			}
+
+			// Split the suffix annotation, e.g. e4!?.
+			if i := strings.IndexAny(s, "!?"); i > 0 {
+				r.unread(token{tokenNAG, s[i:]})
+				s = s[:i]
+			} else if i == 0 {
+				return token{tokenNAG, s}, nil
+			}
+
+			// Skip the move number, e.g. 12. or 12...
+			if i := strings.IndexByte(s, '.'); i >= 0 && strings.Trim(s[:i], "0123456789") == "" {
+				if s = strings.TrimLeft(s[i:], "."); s == "" {
+					continue
+				}
+			}
+			return token{tokenSymbol, s}, nil
+		}
+	}
+}
+
+// readUntil reads the bytes until delim.
+// delim is consumed, but not included in the result.
+func (r *Reader) readUntil(delim byte) (string, error) {
+	var sb strings.Builder
+	for {
+		c, err := r.readByte()
+		if err != nil {
+			return sb.String(), err
+		}
+		if c == delim {
+			return sb.String(), nil
+		}
+		sb.WriteByte(c)
+	}
+}
+
+// readString reads a quoted string after the opening quote.
+func (r *Reader) readString() (string, error) {
+	var sb strings.Builder
+	for {
+		c, err := r.readByte()
+		if err == io.EOF || c == '\n' {
+			return "", errors.New("unterminated string")
+		} else if err != nil {
+			return "", err
+		}
+		if c == '"' {
+			return sb.String(), nil
+		}
+		if c == '\\' {
+			if c, err = r.readByte(); err != nil {
+				return "", errors.New("unterminated string")
+			}
+		}
+		sb.WriteByte(c)
+	}
+}
+
+// readSymbol reads a symbol made of letters, digits and _+#=:-/.!?*
+func (r *Reader) readSymbol() (string, error) {
+	var sb strings.Builder
+	for {
+		c, err := r.readByte()
+		if err == io.EOF {
+			return sb.String(), nil
+		} else if err != nil {
+			return "", err
+		}
+		if !isSymbolByte(c) {
+			r.unreadByte(c)
+			return sb.String(), nil
+		}
+		sb.WriteByte(c)
+	}
+}
+
+func isSymbolByte(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		strings.IndexByte("_+#=:-/.!?*", c) >= 0
+}
+
+func isResult(s string) bool {
+	return s == WhiteWins || s == BlackWins || s == Draw || s == NoResult
+}
+
+// parseNAG parses a $N or a suffix annotation.
+func parseNAG(s string) (int, error) {
+	if nag, ok := suffixToNAG[s]; ok {
+		return nag, nil
+	}
+	nag, err := strconv.Atoi(strings.TrimPrefix(s, "$"))
+	if err != nil || !strings.HasPrefix(s, "$") || nag < 0 || nag > 255 {
+		return 0, fmt.Errorf("invalid annotation %s", s)
+	}
+	return nag, nil
+}
+
+// joinComments joins two comments of the same move.
+func joinComments(a, b string) string {
+	if a == "" {
+		return b
+	}
+	return a + " " + b
+}
@@ -0,0 +1,142 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pgn
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "bitbucket.org/zurichess/board"
+)
+
+const testPGN = `[Event "Casual \"blitz\" game"]
+[Site "?"]
+[White "Anderssen"]
+[Black "Kieseritzky"]
+[Result "1-0"]
+
+{Before the game.} 1. e4 e5 2. f4 exf4 3.Bc4 Qh4+!? 4. Kf1 $6 b5 {The bishop
+is attacked.} (4... Nf6 5. Nc3 (5. e5) 5... c6) 5. Bxb5 1-0
+
+; a comment line
+% an escaped line
+[Event "Second"]
+[SetUp "1"]
+[FEN "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1"]
+
+1. e4 Kd7 2. e5 *
+[Event "Without result"]
+
+1. d4 d5
+`
+
+// uci converts moves in UCI format to moves played from pos.
+func uci(pos *Position, moves string) []Move {
+	var r []Move
+	for _, s := range strings.Fields(moves) {
+		m, err := pos.UCIToMove(s)
+		if err != nil {
+			panic(err)
+		}
+		r = append(r, m)
+		pos.DoMove(m)
+	}
+	for range r {
+		pos.UndoMove()
+	}
+	return r
+}
+
+func TestReader(t *testing.T) {
+	r := NewReader(strings.NewReader(testPGN))
+	g, err := r.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Tag("Event") != `Casual "blitz" game` || g.Tag("White") != "Anderssen" || g.Result != WhiteWins {
+		t.Errorf("got tags %v and result %s", g.Tags, g.Result)
+	}
+
+	pos, _ := g.Position()
+	want := uci(pos, "e2e4 e7e5 f2f4 e5f4 f1c4 d8h4 e1f1 b7b5 c4b5")
+	if got := g.MainLine(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got main line %v, wanted %v", got, want)
+	}
+	if g.Moves[0].Before != "Before the game." {
+		t.Errorf("got comment %q before the first move", g.Moves[0].Before)
+	}
+	if nags := g.Moves[5].NAGs; !reflect.DeepEqual(nags, []int{5}) {
+		t.Errorf("got NAGs %v for Qh4+!?, wanted [5]", nags)
+	}
+	if nags := g.Moves[6].NAGs; !reflect.DeepEqual(nags, []int{6}) {
+		t.Errorf("got NAGs %v for Kf1, wanted [6]", nags)
+	}
+	if comment := g.Moves[7].Comment; comment != "The bishop is attacked." {
+		t.Errorf("got comment %q", comment)
+	}
+
+	// Check the nested variations.
+	if len(g.Moves[7].Variations) != 1 {
+		t.Fatalf("got %d variations, wanted 1", len(g.Moves[7].Variations))
+	}
+	v := g.Moves[7].Variations[0]
+	if len(v) != 3 || v[0].Move.UCI() != "g8f6" || len(v[1].Variations) != 1 {
+		t.Errorf("got unexpected variation %v", v)
+	}
+
+	g, err = r.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Tag("Event") != "Second" || len(g.Moves) != 3 || g.Result != NoResult {
+		t.Errorf("got unexpected second game %+v", g)
+	}
+
+	g, err = r.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Tag("Event") != "Without result" || len(g.Moves) != 2 || g.Result != NoResult {
+		t.Errorf("got unexpected third game %+v", g)
+	}
+
+	if _, err := r.Read(); err != io.EOF {
+		t.Errorf("got %v, wanted EOF", err)
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	for _, s := range []string{
+		"1. e5 *",
+		"1. e4 (1. d4 *",
+		"1. e4 ) *",
+		"( 1. e4 ) *",
+		"$1 1. e4 *",
+		"1. e4 $x *",
+		"[Event \"x] 1. e4 *",
+		"[Event] 1. e4 *",
+		"1. e4 {unterminated",
+		"[FEN \"invalid\"]\n1. e4 *",
+	} {
+		r := NewReader(strings.NewReader(s + "\n[Event \"Next\"]\n1. d4 *\n"))
+		if g, err := r.Read(); err == nil {
+			t.Errorf("%q: got %+v, expected error", s, g)
+			continue
+		}
+
+		// The reader recovers at the next game.
+		for {
+			g, err := r.Read()
+			if err == io.EOF {
+				break
+			}
+			if err == nil && g.Tag("Event") != "Next" {
+				t.Errorf("%q: got unexpected game %+v", s, g)
+			}
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pgn
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	. "bitbucket.org/zurichess/board"
+	"bitbucket.org/zurichess/zurichess/engine"
+)
+
+// sevenTagRoster are the tags written first, in this order, by the Writer.
+var sevenTagRoster = []Tag{
+	{"Event", "?"},
+	{"Site", "?"},
+	{"Date", "????.??.??"},
+	{"Round", "?"},
+	{"White", "?"},
+	{"Black", "?"},
+	{resultTag, NoResult},
+}
+
+// Writer writes games in PGN export format.
+type Writer struct {
+	w *bufio.Writer
+}
+
+// NewWriter returns a new writer writing games to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// Write writes the game g followed by an empty line.
+// The Result tag is always set to g.Result.
+func (w *Writer) Write(g *Game) error {
+	pos, err := g.Position()
+	if err != nil {
+		return err
+	}
+	result := g.Result
+	if result == "" {
+		result = NoResult
+	}
+
+	// Write the tags, the seven tag roster first.
+	for _, t := range sevenTagRoster {
+		value := g.Tag(t.Name)
+		if t.Name == resultTag {
+			value = result
+		} else if value == "" {
+			value = t.Value
+		}
+		writeTag(w.w, t.Name, value)
+	}
+	for _, t := range g.Tags {
+		if !inSevenTagRoster(t.Name) {
+			writeTag(w.w, t.Name, t.Value)
+		}
+	}
+	w.w.WriteString("\n")
+
+	// Write the movetext.
+	mw := &movetextWriter{w: w.w}
+	mw.writeLine(pos, g.Moves)
+	mw.write(result)
+	w.w.WriteString("\n\n")
+	return w.w.Flush()
+}
+
+func inSevenTagRoster(name string) bool {
+	for _, t := range sevenTagRoster {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func writeTag(w *bufio.Writer, name, value string) {
+	value = strings.Replace(value, `\`, `\\`, -1)
+	value = strings.Replace(value, `"`, `\"`, -1)
+	fmt.Fprintf(w, "[%s \"%s\"]\n", name, value)
+}
+
+// movetextWriter writes tokens wrapping lines at maxLineLen.
+type movetextWriter struct {
+	w       *bufio.Writer
+	lineLen int  // length of the current line
+	glue    bool // true if the next token follows without a space
+}
+
+// write writes the token s.
+func (mw *movetextWriter) write(s string) {
+	if mw.lineLen > 0 && mw.lineLen+1+len(s) > maxLineLen {
+		mw.w.WriteString("\n")
+		mw.lineLen = 0
+	} else if mw.lineLen > 0 && !mw.glue {
+		mw.w.WriteString(" ")
+		mw.lineLen++
+	}
+	mw.w.WriteString(s)
+	mw.lineLen += len(s)
+	mw.glue = false
+}
+
+// writeComment writes the comment splitting it at spaces.
+func (mw *movetextWriter) writeComment(comment string) {
+	words := strings.Fields(strings.Replace(comment, "}", "", -1))
+	if len(words) == 0 {
+		mw.write("{}")
+		return
+	}
+	words[0] = "{" + words[0]
+	words[len(words)-1] += "}"
+	for _, w := range words {
+		mw.write(w)
+	}
+}
+
+// writeLine writes the moves in line played from pos. pos is not modified.
+func (mw *movetextWriter) writeLine(pos *Position, line []*Node) {
+	needNumber := true
+	for _, n := range line {
+		if n.Before != "" {
+			mw.writeComment(n.Before)
+			needNumber = true
+		}
+		if pos.Us() == White {
+			mw.write(fmt.Sprintf("%d.", pos.FullmoveCounter()))
+		} else if needNumber {
+			mw.write(fmt.Sprintf("%d...", pos.FullmoveCounter()))
+		}
+		mw.write(engine.MoveToSAN(pos, n.Move))
+		for _, nag := range n.NAGs {
+			mw.write(fmt.Sprintf("$%d", nag))
+		}
+		if n.Comment != "" {
+			mw.writeComment(n.Comment)
+		}
+
+		// Variations are played from the position before the move.
+		for _, v := range n.Variations {
+			mw.write("(")
+			mw.glue = true
+			mw.writeLine(pos, v)
+			mw.glue = true
+			mw.write(")")
+		}
+
+		needNumber = n.Comment != "" || len(n.Variations) != 0
+		pos.DoMove(n.Move)
+	}
+	for range line {
+		pos.UndoMove()
+	}
+}
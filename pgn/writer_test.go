@@ -0,0 +1,124 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pgn
+
+import (
+	"bytes"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	. "bitbucket.org/zurichess/board"
+	. "bitbucket.org/zurichess/zurichess/internal/testdata"
+)
+
+func TestWriter(t *testing.T) {
+	pos, _ := PositionFromFEN(FENStartPos)
+	g := NewGame(nil, uci(pos, "e2e4 e7e5 g1f3 b8c6"))
+	g.SetTag("White", `Some "quoted" name`)
+	g.Result = Draw
+	g.Moves[1].NAGs = []int{2}
+	g.Moves[1].Comment = "A comment."
+	g.Moves[2].Variations = [][]*Node{{{Move: uci(pos, "e2e4 e7e5 f1c4")[2]}}}
+	pos.DoMove(g.Moves[0].Move)
+	pos.DoMove(g.Moves[1].Move)
+	g.Moves[2].Variations[0][0].Before = "Or"
+	pos.UndoMove()
+	pos.UndoMove()
+
+	buf := &bytes.Buffer{}
+	if err := NewWriter(buf).Write(g); err != nil {
+		t.Fatal(err)
+	}
+	want := `[Event "?"]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "Some \"quoted\" name"]
+[Black "?"]
+[Result "1/2-1/2"]
+
+1. e4 e5 $2 {A comment.} 2. Nf3 ({Or} 2. Bc4) 2... Nc6 1/2-1/2
+
+`
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwanted\n%s", buf.String(), want)
+	}
+}
+
+func TestWriterLongLines(t *testing.T) {
+	pos, _ := PositionFromFEN(FENStartPos)
+	g := NewGame(nil, uci(pos, TestGames[1]))
+	g.Moves[3].Comment = strings.Repeat("very long comment ", 20)
+
+	buf := &bytes.Buffer{}
+	NewWriter(buf).Write(g)
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if len(line) > maxLineLen {
+			t.Errorf("line %q is longer than %d", line, maxLineLen)
+		}
+	}
+}
+
+// Tests that games can be written and read back.
+func TestRoundTrip(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewWriter(buf)
+	var games []*Game
+	for i, game := range TestGames {
+		pos, _ := PositionFromFEN(FENStartPos)
+		g := NewGame(nil, uci(pos, game))
+		g.SetTag("White", "Player "+strconv.Itoa(i+1))
+		g.Result = []string{WhiteWins, BlackWins, Draw}[i%3]
+		if err := w.Write(g); err != nil {
+			t.Fatal(err)
+		}
+		games = append(games, g)
+	}
+
+	// Games with annotations and a different starting position.
+	r := NewReader(strings.NewReader(testPGN))
+	for {
+		g, err := r.Read()
+		if err != nil {
+			break
+		}
+		if err := w.Write(g); err != nil {
+			t.Fatal(err)
+		}
+		games = append(games, g)
+	}
+
+	written := buf.String()
+	r = NewReader(strings.NewReader(written))
+	for i, want := range games {
+		got, err := r.Read()
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if !reflect.DeepEqual(got.Moves, want.Moves) {
+			t.Errorf("#%d: got moves %v, wanted %v", i, got.MainLine(), want.MainLine())
+		}
+		if got.Result != want.Result {
+			t.Errorf("#%d: got result %s, wanted %s", i, got.Result, want.Result)
+		}
+		for _, tag := range want.Tags {
+			if got.Tag(tag.Name) != tag.Value {
+				t.Errorf("#%d: got tag %s %q, wanted %q", i, tag.Name, got.Tag(tag.Name), tag.Value)
+			}
+		}
+	}
+
+	// Writing again gives the same output.
+	buf.Reset()
+	r = NewReader(strings.NewReader(written))
+	for g, err := r.Read(); err == nil; g, err = r.Read() {
+		w.Write(g)
+	}
+	if buf.String() != written {
+		t.Errorf("got\n%s\nwanted\n%s", buf.String(), written)
+	}
+}
@@ -0,0 +1,1149 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.25.0
+// 	protoc        (unknown)
+// source: engine.proto
+
+package rpc
+
+import (
+	context "context"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// This is a compile-time assertion that a sufficiently up-to-date version
+// of the legacy proto package is being used.
+const _ = proto.ProtoPackageIsVersion4
+
+// Options configure the engine for one request.
+type Options struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Number of principal variations, defaults to 1.
+	MultiPv int32 `protobuf:"varint,1,opt,name=multi_pv,json=multiPv,proto3" json:"multi_pv,omitempty"`
+	// Handicap level, 0 for full strength.
+	HandicapLevel int32 `protobuf:"varint,2,opt,name=handicap_level,json=handicapLevel,proto3" json:"handicap_level,omitempty"`
+	// Transposition table size in MB, 0 for the server's default.
+	Hash int32 `protobuf:"varint,3,opt,name=hash,proto3" json:"hash,omitempty"`
+}
+
+func (x *Options) Reset() {
+	*x = Options{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_engine_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Options) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Options) ProtoMessage() {}
+
+func (x *Options) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Options.ProtoReflect.Descriptor instead.
+func (*Options) Descriptor() ([]byte, []int) {
+	return file_engine_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Options) GetMultiPv() int32 {
+	if x != nil {
+		return x.MultiPv
+	}
+	return 0
+}
+
+func (x *Options) GetHandicapLevel() int32 {
+	if x != nil {
+		return x.HandicapLevel
+	}
+	return 0
+}
+
+func (x *Options) GetHash() int32 {
+	if x != nil {
+		return x.Hash
+	}
+	return 0
+}
+
+// SearchRequest describes a position and the search limits.
+type SearchRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Position in FEN, defaults to the start position.
+	Fen string `protobuf:"bytes,1,opt,name=fen,proto3" json:"fen,omitempty"`
+	// Moves in UCI format played from fen.
+	Moves []string `protobuf:"bytes,2,rep,name=moves,proto3" json:"moves,omitempty"`
+	// Maximum search depth, 0 for no limit.
+	Depth int32 `protobuf:"varint,3,opt,name=depth,proto3" json:"depth,omitempty"`
+	// Search time in milliseconds, 0 for no limit.
+	MoveTime int64    `protobuf:"varint,4,opt,name=move_time,json=moveTime,proto3" json:"move_time,omitempty"`
+	Options  *Options `protobuf:"bytes,5,opt,name=options,proto3" json:"options,omitempty"`
+}
+
+func (x *SearchRequest) Reset() {
+	*x = SearchRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_engine_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SearchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchRequest) ProtoMessage() {}
+
+func (x *SearchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchRequest.ProtoReflect.Descriptor instead.
+func (*SearchRequest) Descriptor() ([]byte, []int) {
+	return file_engine_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SearchRequest) GetFen() string {
+	if x != nil {
+		return x.Fen
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetMoves() []string {
+	if x != nil {
+		return x.Moves
+	}
+	return nil
+}
+
+func (x *SearchRequest) GetDepth() int32 {
+	if x != nil {
+		return x.Depth
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetMoveTime() int64 {
+	if x != nil {
+		return x.MoveTime
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetOptions() *Options {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+// Score is a search score from the side to move's point of view.
+type Score struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Value:
+	//	*Score_Cp
+	//	*Score_Mate
+	Value isScore_Value `protobuf_oneof:"value"`
+}
+
+func (x *Score) Reset() {
+	*x = Score{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_engine_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Score) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Score) ProtoMessage() {}
+
+func (x *Score) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Score.ProtoReflect.Descriptor instead.
+func (*Score) Descriptor() ([]byte, []int) {
+	return file_engine_proto_rawDescGZIP(), []int{2}
+}
+
+func (m *Score) GetValue() isScore_Value {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (x *Score) GetCp() int32 {
+	if x, ok := x.GetValue().(*Score_Cp); ok {
+		return x.Cp
+	}
+	return 0
+}
+
+func (x *Score) GetMate() int32 {
+	if x, ok := x.GetValue().(*Score_Mate); ok {
+		return x.Mate
+	}
+	return 0
+}
+
+type isScore_Value interface {
+	isScore_Value()
+}
+
+type Score_Cp struct {
+	// Score in centipawns.
+	Cp int32 `protobuf:"varint,1,opt,name=cp,proto3,oneof"`
+}
+
+type Score_Mate struct {
+	// Moves to mate, negative if the side to move is mated.
+	Mate int32 `protobuf:"varint,2,opt,name=mate,proto3,oneof"`
+}
+
+func (*Score_Cp) isScore_Value() {}
+
+func (*Score_Mate) isScore_Value() {}
+
+// SearchInfo is the principal variation found after completing one depth.
+type SearchInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Depth    int32 `protobuf:"varint,1,opt,name=depth,proto3" json:"depth,omitempty"`
+	SelDepth int32 `protobuf:"varint,2,opt,name=sel_depth,json=selDepth,proto3" json:"sel_depth,omitempty"`
+	// 1-based index of the principal variation.
+	MultiPv int32  `protobuf:"varint,3,opt,name=multi_pv,json=multiPv,proto3" json:"multi_pv,omitempty"`
+	Score   *Score `protobuf:"bytes,4,opt,name=score,proto3" json:"score,omitempty"`
+	Nodes   uint64 `protobuf:"varint,5,opt,name=nodes,proto3" json:"nodes,omitempty"`
+	Nps     uint64 `protobuf:"varint,6,opt,name=nps,proto3" json:"nps,omitempty"`
+	// Milliseconds since the search started.
+	Time int64 `protobuf:"varint,7,opt,name=time,proto3" json:"time,omitempty"`
+	// Principal variation in UCI format.
+	Pv []string `protobuf:"bytes,8,rep,name=pv,proto3" json:"pv,omitempty"`
+	// Best move in UCI format, set only at the end of the search.
+	BestMove string `protobuf:"bytes,9,opt,name=best_move,json=bestMove,proto3" json:"best_move,omitempty"`
+	// Expected reply in UCI format, set only at the end of the search.
+	Ponder string `protobuf:"bytes,10,opt,name=ponder,proto3" json:"ponder,omitempty"`
+}
+
+func (x *SearchInfo) Reset() {
+	*x = SearchInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_engine_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SearchInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchInfo) ProtoMessage() {}
+
+func (x *SearchInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchInfo.ProtoReflect.Descriptor instead.
+func (*SearchInfo) Descriptor() ([]byte, []int) {
+	return file_engine_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SearchInfo) GetDepth() int32 {
+	if x != nil {
+		return x.Depth
+	}
+	return 0
+}
+
+func (x *SearchInfo) GetSelDepth() int32 {
+	if x != nil {
+		return x.SelDepth
+	}
+	return 0
+}
+
+func (x *SearchInfo) GetMultiPv() int32 {
+	if x != nil {
+		return x.MultiPv
+	}
+	return 0
+}
+
+func (x *SearchInfo) GetScore() *Score {
+	if x != nil {
+		return x.Score
+	}
+	return nil
+}
+
+func (x *SearchInfo) GetNodes() uint64 {
+	if x != nil {
+		return x.Nodes
+	}
+	return 0
+}
+
+func (x *SearchInfo) GetNps() uint64 {
+	if x != nil {
+		return x.Nps
+	}
+	return 0
+}
+
+func (x *SearchInfo) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *SearchInfo) GetPv() []string {
+	if x != nil {
+		return x.Pv
+	}
+	return nil
+}
+
+func (x *SearchInfo) GetBestMove() string {
+	if x != nil {
+		return x.BestMove
+	}
+	return ""
+}
+
+func (x *SearchInfo) GetPonder() string {
+	if x != nil {
+		return x.Ponder
+	}
+	return ""
+}
+
+type AnalyseResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The deepest principal variations ordered by multi_pv.
+	Lines    []*SearchInfo `protobuf:"bytes,1,rep,name=lines,proto3" json:"lines,omitempty"`
+	BestMove string        `protobuf:"bytes,2,opt,name=best_move,json=bestMove,proto3" json:"best_move,omitempty"`
+	Ponder   string        `protobuf:"bytes,3,opt,name=ponder,proto3" json:"ponder,omitempty"`
+	Score    *Score        `protobuf:"bytes,4,opt,name=score,proto3" json:"score,omitempty"`
+}
+
+func (x *AnalyseResponse) Reset() {
+	*x = AnalyseResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_engine_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AnalyseResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnalyseResponse) ProtoMessage() {}
+
+func (x *AnalyseResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnalyseResponse.ProtoReflect.Descriptor instead.
+func (*AnalyseResponse) Descriptor() ([]byte, []int) {
+	return file_engine_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *AnalyseResponse) GetLines() []*SearchInfo {
+	if x != nil {
+		return x.Lines
+	}
+	return nil
+}
+
+func (x *AnalyseResponse) GetBestMove() string {
+	if x != nil {
+		return x.BestMove
+	}
+	return ""
+}
+
+func (x *AnalyseResponse) GetPonder() string {
+	if x != nil {
+		return x.Ponder
+	}
+	return ""
+}
+
+func (x *AnalyseResponse) GetScore() *Score {
+	if x != nil {
+		return x.Score
+	}
+	return nil
+}
+
+type BestMoveResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	BestMove string `protobuf:"bytes,1,opt,name=best_move,json=bestMove,proto3" json:"best_move,omitempty"`
+	Ponder   string `protobuf:"bytes,2,opt,name=ponder,proto3" json:"ponder,omitempty"`
+	Score    *Score `protobuf:"bytes,3,opt,name=score,proto3" json:"score,omitempty"`
+}
+
+func (x *BestMoveResponse) Reset() {
+	*x = BestMoveResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_engine_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BestMoveResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BestMoveResponse) ProtoMessage() {}
+
+func (x *BestMoveResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BestMoveResponse.ProtoReflect.Descriptor instead.
+func (*BestMoveResponse) Descriptor() ([]byte, []int) {
+	return file_engine_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *BestMoveResponse) GetBestMove() string {
+	if x != nil {
+		return x.BestMove
+	}
+	return ""
+}
+
+func (x *BestMoveResponse) GetPonder() string {
+	if x != nil {
+		return x.Ponder
+	}
+	return ""
+}
+
+func (x *BestMoveResponse) GetScore() *Score {
+	if x != nil {
+		return x.Score
+	}
+	return nil
+}
+
+type EvaluateRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Position in FEN, defaults to the start position.
+	Fen string `protobuf:"bytes,1,opt,name=fen,proto3" json:"fen,omitempty"`
+	// Moves in UCI format played from fen.
+	Moves []string `protobuf:"bytes,2,rep,name=moves,proto3" json:"moves,omitempty"`
+}
+
+func (x *EvaluateRequest) Reset() {
+	*x = EvaluateRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_engine_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EvaluateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EvaluateRequest) ProtoMessage() {}
+
+func (x *EvaluateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EvaluateRequest.ProtoReflect.Descriptor instead.
+func (*EvaluateRequest) Descriptor() ([]byte, []int) {
+	return file_engine_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *EvaluateRequest) GetFen() string {
+	if x != nil {
+		return x.Fen
+	}
+	return ""
+}
+
+func (x *EvaluateRequest) GetMoves() []string {
+	if x != nil {
+		return x.Moves
+	}
+	return nil
+}
+
+type EvaluateResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Fen string `protobuf:"bytes,1,opt,name=fen,proto3" json:"fen,omitempty"`
+	// Score in centipawns from the side to move's point of view.
+	Score int32 `protobuf:"varint,2,opt,name=score,proto3" json:"score,omitempty"`
+	// Score in centipawns from white's point of view.
+	White int32 `protobuf:"varint,3,opt,name=white,proto3" json:"white,omitempty"`
+	// Game phase, 0 is opening, 256 is late end game.
+	Phase int32 `protobuf:"varint,4,opt,name=phase,proto3" json:"phase,omitempty"`
+}
+
+func (x *EvaluateResponse) Reset() {
+	*x = EvaluateResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_engine_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EvaluateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EvaluateResponse) ProtoMessage() {}
+
+func (x *EvaluateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EvaluateResponse.ProtoReflect.Descriptor instead.
+func (*EvaluateResponse) Descriptor() ([]byte, []int) {
+	return file_engine_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *EvaluateResponse) GetFen() string {
+	if x != nil {
+		return x.Fen
+	}
+	return ""
+}
+
+func (x *EvaluateResponse) GetScore() int32 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *EvaluateResponse) GetWhite() int32 {
+	if x != nil {
+		return x.White
+	}
+	return 0
+}
+
+func (x *EvaluateResponse) GetPhase() int32 {
+	if x != nil {
+		return x.Phase
+	}
+	return 0
+}
+
+var File_engine_proto protoreflect.FileDescriptor
+
+var file_engine_proto_rawDesc = []byte{
+	0x0a, 0x0c, 0x65, 0x6e, 0x67, 0x69, 0x6e, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x09,
+	0x7a, 0x75, 0x72, 0x69, 0x63, 0x68, 0x65, 0x73, 0x73, 0x22, 0x5f, 0x0a, 0x07, 0x4f, 0x70, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x12, 0x19, 0x0a, 0x08, 0x6d, 0x75, 0x6c, 0x74, 0x69, 0x5f, 0x70, 0x76,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x6d, 0x75, 0x6c, 0x74, 0x69, 0x50, 0x76, 0x12,
+	0x25, 0x0a, 0x0e, 0x68, 0x61, 0x6e, 0x64, 0x69, 0x63, 0x61, 0x70, 0x5f, 0x6c, 0x65, 0x76, 0x65,
+	0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0d, 0x68, 0x61, 0x6e, 0x64, 0x69, 0x63, 0x61,
+	0x70, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x61, 0x73, 0x68, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x68, 0x61, 0x73, 0x68, 0x22, 0x98, 0x01, 0x0a, 0x0d, 0x53,
+	0x65, 0x61, 0x72, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03,
+	0x66, 0x65, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x66, 0x65, 0x6e, 0x12, 0x14,
+	0x0a, 0x05, 0x6d, 0x6f, 0x76, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x6d,
+	0x6f, 0x76, 0x65, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x64, 0x65, 0x70, 0x74, 0x68, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x05, 0x64, 0x65, 0x70, 0x74, 0x68, 0x12, 0x1b, 0x0a, 0x09, 0x6d, 0x6f,
+	0x76, 0x65, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x6d,
+	0x6f, 0x76, 0x65, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x2c, 0x0a, 0x07, 0x6f, 0x70, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x7a, 0x75, 0x72, 0x69, 0x63,
+	0x68, 0x65, 0x73, 0x73, 0x2e, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x07, 0x6f, 0x70,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x38, 0x0a, 0x05, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x12, 0x10,
+	0x0a, 0x02, 0x63, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x48, 0x00, 0x52, 0x02, 0x63, 0x70,
+	0x12, 0x14, 0x0a, 0x04, 0x6d, 0x61, 0x74, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x48, 0x00,
+	0x52, 0x04, 0x6d, 0x61, 0x74, 0x65, 0x42, 0x07, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22,
+	0x83, 0x02, 0x0a, 0x0a, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x14,
+	0x0a, 0x05, 0x64, 0x65, 0x70, 0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x64,
+	0x65, 0x70, 0x74, 0x68, 0x12, 0x1b, 0x0a, 0x09, 0x73, 0x65, 0x6c, 0x5f, 0x64, 0x65, 0x70, 0x74,
+	0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x73, 0x65, 0x6c, 0x44, 0x65, 0x70, 0x74,
+	0x68, 0x12, 0x19, 0x0a, 0x08, 0x6d, 0x75, 0x6c, 0x74, 0x69, 0x5f, 0x70, 0x76, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x07, 0x6d, 0x75, 0x6c, 0x74, 0x69, 0x50, 0x76, 0x12, 0x26, 0x0a, 0x05,
+	0x73, 0x63, 0x6f, 0x72, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x7a, 0x75,
+	0x72, 0x69, 0x63, 0x68, 0x65, 0x73, 0x73, 0x2e, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x52, 0x05, 0x73,
+	0x63, 0x6f, 0x72, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x6f, 0x64, 0x65, 0x73, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x05, 0x6e, 0x6f, 0x64, 0x65, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x6e, 0x70,
+	0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x6e, 0x70, 0x73, 0x12, 0x12, 0x0a, 0x04,
+	0x74, 0x69, 0x6d, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x74, 0x69, 0x6d, 0x65,
+	0x12, 0x0e, 0x0a, 0x02, 0x70, 0x76, 0x18, 0x08, 0x20, 0x03, 0x28, 0x09, 0x52, 0x02, 0x70, 0x76,
+	0x12, 0x1b, 0x0a, 0x09, 0x62, 0x65, 0x73, 0x74, 0x5f, 0x6d, 0x6f, 0x76, 0x65, 0x18, 0x09, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x08, 0x62, 0x65, 0x73, 0x74, 0x4d, 0x6f, 0x76, 0x65, 0x12, 0x16, 0x0a,
+	0x06, 0x70, 0x6f, 0x6e, 0x64, 0x65, 0x72, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x70,
+	0x6f, 0x6e, 0x64, 0x65, 0x72, 0x22, 0x9b, 0x01, 0x0a, 0x0f, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x73,
+	0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2b, 0x0a, 0x05, 0x6c, 0x69, 0x6e,
+	0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x7a, 0x75, 0x72, 0x69, 0x63,
+	0x68, 0x65, 0x73, 0x73, 0x2e, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x49, 0x6e, 0x66, 0x6f, 0x52,
+	0x05, 0x6c, 0x69, 0x6e, 0x65, 0x73, 0x12, 0x1b, 0x0a, 0x09, 0x62, 0x65, 0x73, 0x74, 0x5f, 0x6d,
+	0x6f, 0x76, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x62, 0x65, 0x73, 0x74, 0x4d,
+	0x6f, 0x76, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x6f, 0x6e, 0x64, 0x65, 0x72, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x70, 0x6f, 0x6e, 0x64, 0x65, 0x72, 0x12, 0x26, 0x0a, 0x05, 0x73,
+	0x63, 0x6f, 0x72, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x7a, 0x75, 0x72,
+	0x69, 0x63, 0x68, 0x65, 0x73, 0x73, 0x2e, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x52, 0x05, 0x73, 0x63,
+	0x6f, 0x72, 0x65, 0x22, 0x6f, 0x0a, 0x10, 0x42, 0x65, 0x73, 0x74, 0x4d, 0x6f, 0x76, 0x65, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x62, 0x65, 0x73, 0x74, 0x5f,
+	0x6d, 0x6f, 0x76, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x62, 0x65, 0x73, 0x74,
+	0x4d, 0x6f, 0x76, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x6f, 0x6e, 0x64, 0x65, 0x72, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x70, 0x6f, 0x6e, 0x64, 0x65, 0x72, 0x12, 0x26, 0x0a, 0x05,
+	0x73, 0x63, 0x6f, 0x72, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x7a, 0x75,
+	0x72, 0x69, 0x63, 0x68, 0x65, 0x73, 0x73, 0x2e, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x52, 0x05, 0x73,
+	0x63, 0x6f, 0x72, 0x65, 0x22, 0x39, 0x0a, 0x0f, 0x45, 0x76, 0x61, 0x6c, 0x75, 0x61, 0x74, 0x65,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x66, 0x65, 0x6e, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x66, 0x65, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x6d, 0x6f, 0x76,
+	0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x6d, 0x6f, 0x76, 0x65, 0x73, 0x22,
+	0x66, 0x0a, 0x10, 0x45, 0x76, 0x61, 0x6c, 0x75, 0x61, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x66, 0x65, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x03, 0x66, 0x65, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x77,
+	0x68, 0x69, 0x74, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x77, 0x68, 0x69, 0x74,
+	0x65, 0x12, 0x14, 0x0a, 0x05, 0x70, 0x68, 0x61, 0x73, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x05, 0x70, 0x68, 0x61, 0x73, 0x65, 0x32, 0x96, 0x02, 0x0a, 0x06, 0x45, 0x6e, 0x67, 0x69,
+	0x6e, 0x65, 0x12, 0x3f, 0x0a, 0x07, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x65, 0x12, 0x18, 0x2e,
+	0x7a, 0x75, 0x72, 0x69, 0x63, 0x68, 0x65, 0x73, 0x73, 0x2e, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e, 0x7a, 0x75, 0x72, 0x69, 0x63, 0x68,
+	0x65, 0x73, 0x73, 0x2e, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x41, 0x0a, 0x08, 0x42, 0x65, 0x73, 0x74, 0x4d, 0x6f, 0x76, 0x65, 0x12,
+	0x18, 0x2e, 0x7a, 0x75, 0x72, 0x69, 0x63, 0x68, 0x65, 0x73, 0x73, 0x2e, 0x53, 0x65, 0x61, 0x72,
+	0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x7a, 0x75, 0x72, 0x69,
+	0x63, 0x68, 0x65, 0x73, 0x73, 0x2e, 0x42, 0x65, 0x73, 0x74, 0x4d, 0x6f, 0x76, 0x65, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x43, 0x0a, 0x08, 0x45, 0x76, 0x61, 0x6c, 0x75, 0x61,
+	0x74, 0x65, 0x12, 0x1a, 0x2e, 0x7a, 0x75, 0x72, 0x69, 0x63, 0x68, 0x65, 0x73, 0x73, 0x2e, 0x45,
+	0x76, 0x61, 0x6c, 0x75, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b,
+	0x2e, 0x7a, 0x75, 0x72, 0x69, 0x63, 0x68, 0x65, 0x73, 0x73, 0x2e, 0x45, 0x76, 0x61, 0x6c, 0x75,
+	0x61, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x43, 0x0a, 0x0e, 0x53,
+	0x65, 0x61, 0x72, 0x63, 0x68, 0x50, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73, 0x73, 0x12, 0x18, 0x2e,
+	0x7a, 0x75, 0x72, 0x69, 0x63, 0x68, 0x65, 0x73, 0x73, 0x2e, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x15, 0x2e, 0x7a, 0x75, 0x72, 0x69, 0x63, 0x68,
+	0x65, 0x73, 0x73, 0x2e, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x49, 0x6e, 0x66, 0x6f, 0x30, 0x01,
+	0x42, 0x27, 0x5a, 0x25, 0x62, 0x69, 0x74, 0x62, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x2e, 0x6f, 0x72,
+	0x67, 0x2f, 0x7a, 0x75, 0x72, 0x69, 0x63, 0x68, 0x65, 0x73, 0x73, 0x2f, 0x7a, 0x75, 0x72, 0x69,
+	0x63, 0x68, 0x65, 0x73, 0x73, 0x2f, 0x72, 0x70, 0x63, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x33,
+}
+
+var (
+	file_engine_proto_rawDescOnce sync.Once
+	file_engine_proto_rawDescData = file_engine_proto_rawDesc
+)
+
+func file_engine_proto_rawDescGZIP() []byte {
+	file_engine_proto_rawDescOnce.Do(func() {
+		file_engine_proto_rawDescData = protoimpl.X.CompressGZIP(file_engine_proto_rawDescData)
+	})
+	return file_engine_proto_rawDescData
+}
+
+var file_engine_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_engine_proto_goTypes = []interface{}{
+	(*Options)(nil),          // 0: zurichess.Options
+	(*SearchRequest)(nil),    // 1: zurichess.SearchRequest
+	(*Score)(nil),            // 2: zurichess.Score
+	(*SearchInfo)(nil),       // 3: zurichess.SearchInfo
+	(*AnalyseResponse)(nil),  // 4: zurichess.AnalyseResponse
+	(*BestMoveResponse)(nil), // 5: zurichess.BestMoveResponse
+	(*EvaluateRequest)(nil),  // 6: zurichess.EvaluateRequest
+	(*EvaluateResponse)(nil), // 7: zurichess.EvaluateResponse
+}
+var file_engine_proto_depIdxs = []int32{
+	0, // 0: zurichess.SearchRequest.options:type_name -> zurichess.Options
+	2, // 1: zurichess.SearchInfo.score:type_name -> zurichess.Score
+	3, // 2: zurichess.AnalyseResponse.lines:type_name -> zurichess.SearchInfo
+	2, // 3: zurichess.AnalyseResponse.score:type_name -> zurichess.Score
+	2, // 4: zurichess.BestMoveResponse.score:type_name -> zurichess.Score
+	1, // 5: zurichess.Engine.Analyse:input_type -> zurichess.SearchRequest
+	1, // 6: zurichess.Engine.BestMove:input_type -> zurichess.SearchRequest
+	6, // 7: zurichess.Engine.Evaluate:input_type -> zurichess.EvaluateRequest
+	1, // 8: zurichess.Engine.SearchProgress:input_type -> zurichess.SearchRequest
+	4, // 9: zurichess.Engine.Analyse:output_type -> zurichess.AnalyseResponse
+	5, // 10: zurichess.Engine.BestMove:output_type -> zurichess.BestMoveResponse
+	7, // 11: zurichess.Engine.Evaluate:output_type -> zurichess.EvaluateResponse
+	3, // 12: zurichess.Engine.SearchProgress:output_type -> zurichess.SearchInfo
+	9, // [9:13] is the sub-list for method output_type
+	5, // [5:9] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_engine_proto_init() }
+func file_engine_proto_init() {
+	if File_engine_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_engine_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Options); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_engine_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SearchRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_engine_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Score); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_engine_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SearchInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_engine_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AnalyseResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_engine_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BestMoveResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_engine_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EvaluateRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_engine_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EvaluateResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_engine_proto_msgTypes[2].OneofWrappers = []interface{}{
+		(*Score_Cp)(nil),
+		(*Score_Mate)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_engine_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_engine_proto_goTypes,
+		DependencyIndexes: file_engine_proto_depIdxs,
+		MessageInfos:      file_engine_proto_msgTypes,
+	}.Build()
+	File_engine_proto = out.File
+	file_engine_proto_rawDesc = nil
+	file_engine_proto_goTypes = nil
+	file_engine_proto_depIdxs = nil
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// EngineClient is the client API for Engine service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type EngineClient interface {
+	// Analyse searches a position and returns the principal variations.
+	Analyse(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*AnalyseResponse, error)
+	// BestMove searches a position and returns the best move.
+	BestMove(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*BestMoveResponse, error)
+	// Evaluate returns the static evaluation of a position.
+	Evaluate(ctx context.Context, in *EvaluateRequest, opts ...grpc.CallOption) (*EvaluateResponse, error)
+	// SearchProgress searches a position and streams the principal
+	// variations as soon as they are found. The last message has
+	// the best move set.
+	SearchProgress(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (Engine_SearchProgressClient, error)
+}
+
+type engineClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEngineClient(cc grpc.ClientConnInterface) EngineClient {
+	return &engineClient{cc}
+}
+
+func (c *engineClient) Analyse(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*AnalyseResponse, error) {
+	out := new(AnalyseResponse)
+	err := c.cc.Invoke(ctx, "/zurichess.Engine/Analyse", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *engineClient) BestMove(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*BestMoveResponse, error) {
+	out := new(BestMoveResponse)
+	err := c.cc.Invoke(ctx, "/zurichess.Engine/BestMove", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *engineClient) Evaluate(ctx context.Context, in *EvaluateRequest, opts ...grpc.CallOption) (*EvaluateResponse, error) {
+	out := new(EvaluateResponse)
+	err := c.cc.Invoke(ctx, "/zurichess.Engine/Evaluate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *engineClient) SearchProgress(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (Engine_SearchProgressClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Engine_serviceDesc.Streams[0], "/zurichess.Engine/SearchProgress", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &engineSearchProgressClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Engine_SearchProgressClient interface {
+	Recv() (*SearchInfo, error)
+	grpc.ClientStream
+}
+
+type engineSearchProgressClient struct {
+	grpc.ClientStream
+}
+
+func (x *engineSearchProgressClient) Recv() (*SearchInfo, error) {
+	m := new(SearchInfo)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// EngineServer is the server API for Engine service.
+type EngineServer interface {
+	// Analyse searches a position and returns the principal variations.
+	Analyse(context.Context, *SearchRequest) (*AnalyseResponse, error)
+	// BestMove searches a position and returns the best move.
+	BestMove(context.Context, *SearchRequest) (*BestMoveResponse, error)
+	// Evaluate returns the static evaluation of a position.
+	Evaluate(context.Context, *EvaluateRequest) (*EvaluateResponse, error)
+	// SearchProgress searches a position and streams the principal
+	// variations as soon as they are found. The last message has
+	// the best move set.
+	SearchProgress(*SearchRequest, Engine_SearchProgressServer) error
+}
+
+// UnimplementedEngineServer can be embedded to have forward compatible implementations.
+type UnimplementedEngineServer struct {
+}
+
+func (*UnimplementedEngineServer) Analyse(context.Context, *SearchRequest) (*AnalyseResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Analyse not implemented")
+}
+func (*UnimplementedEngineServer) BestMove(context.Context, *SearchRequest) (*BestMoveResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BestMove not implemented")
+}
+func (*UnimplementedEngineServer) Evaluate(context.Context, *EvaluateRequest) (*EvaluateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Evaluate not implemented")
+}
+func (*UnimplementedEngineServer) SearchProgress(*SearchRequest, Engine_SearchProgressServer) error {
+	return status.Errorf(codes.Unimplemented, "method SearchProgress not implemented")
+}
+
+func RegisterEngineServer(s *grpc.Server, srv EngineServer) {
+	s.RegisterService(&_Engine_serviceDesc, srv)
+}
+
+func _Engine_Analyse_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineServer).Analyse(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/zurichess.Engine/Analyse",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineServer).Analyse(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Engine_BestMove_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineServer).BestMove(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/zurichess.Engine/BestMove",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineServer).BestMove(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Engine_Evaluate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EvaluateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineServer).Evaluate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/zurichess.Engine/Evaluate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineServer).Evaluate(ctx, req.(*EvaluateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Engine_SearchProgress_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SearchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EngineServer).SearchProgress(m, &engineSearchProgressServer{stream})
+}
+
+type Engine_SearchProgressServer interface {
+	Send(*SearchInfo) error
+	grpc.ServerStream
+}
+
+type engineSearchProgressServer struct {
+	grpc.ServerStream
+}
+
+func (x *engineSearchProgressServer) Send(m *SearchInfo) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+var _Engine_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "zurichess.Engine",
+	HandlerType: (*EngineServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Analyse",
+			Handler:    _Engine_Analyse_Handler,
+		},
+		{
+			MethodName: "BestMove",
+			Handler:    _Engine_BestMove_Handler,
+		},
+		{
+			MethodName: "Evaluate",
+			Handler:    _Engine_Evaluate_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SearchProgress",
+			Handler:       _Engine_SearchProgress_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "engine.proto",
+}
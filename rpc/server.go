@@ -0,0 +1,264 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package rpc implements a gRPC service for the zurichess engine.
+//
+// The service is described in engine.proto. After changing
+// engine.proto regenerate engine.pb.go with go generate.
+package rpc
+
+//go:generate protoc --go_out=plugins=grpc,paths=source_relative:. engine.proto
+
+import (
+	"context"
+	"time"
+
+	. "bitbucket.org/zurichess/board"
+	"bitbucket.org/zurichess/zurichess/engine"
+	"bitbucket.org/zurichess/zurichess/internal/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Server implements the Engine service.
+//
+// Requests are served by a pool of engines. The size of the
+// transposition tables is the default and the maximum size
+// a request can ask for.
+type Server struct {
+	pool *service.Pool
+}
+
+// NewServer returns a new server with numEngines engines.
+// hashMB is the default and the maximum size of each engine's
+// transposition table.
+func NewServer(numEngines, hashMB int) *Server {
+	return &Server{pool: service.NewPool(numEngines, hashMB)}
+}
+
+// get returns an idle engine. Blocks until an engine is available or ctx is done.
+func (s *Server) get(ctx context.Context) (*engine.Engine, error) {
+	eng, err := s.pool.Get(ctx)
+	if err != nil {
+		return nil, contextError(ctx)
+	}
+	return eng, nil
+}
+
+// contextError converts the error of a done context to a status error.
+func contextError(ctx context.Context) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return status.Error(codes.DeadlineExceeded, ctx.Err().Error())
+	}
+	return status.Error(codes.Canceled, ctx.Err().Error())
+}
+
+// options validates the request options and converts them to engine options.
+func (s *Server) options(opts *Options) (engine.Options, int, error) {
+	multiPV, handicap, hashMB := int(opts.GetMultiPv()), int(opts.GetHandicapLevel()), int(opts.GetHash())
+	if multiPV == 0 {
+		multiPV = 1
+	}
+	if hashMB == 0 {
+		hashMB = s.pool.HashMB()
+	}
+	if multiPV < 1 || multiPV > service.MaxMultiPV {
+		return engine.Options{}, 0, status.Errorf(codes.InvalidArgument, "multi_pv must be between 1 and %d", service.MaxMultiPV)
+	}
+	if handicap < 0 || handicap > service.MaxHandicapLevel {
+		return engine.Options{}, 0, status.Errorf(codes.InvalidArgument, "handicap_level must be between 0 and %d", service.MaxHandicapLevel)
+	}
+	if hashMB < 1 || hashMB > s.pool.HashMB() {
+		return engine.Options{}, 0, status.Errorf(codes.InvalidArgument, "hash must be between 1 and %d", s.pool.HashMB())
+	}
+	return engine.Options{AnalyseMode: true, MultiPV: multiPV, HandicapLevel: handicap}, hashMB, nil
+}
+
+// newScore converts a search score to a Score message.
+func newScore(s int32) *Score {
+	if mate, ok := service.MateIn(s); ok {
+		return &Score{Value: &Score_Mate{Mate: mate}}
+	}
+	return &Score{Value: &Score_Cp{Cp: s}}
+}
+
+// progressLogger implements engine.Logger and
+// collects the principal variations as they are found.
+type progressLogger struct {
+	start time.Time
+	lines []*SearchInfo           // the deepest principal variation for each multi_pv
+	send  func(*SearchInfo) error // if not nil, called for every principal variation
+	err   error                   // first error returned by send
+	stop  func()                  // stops the search
+}
+
+func (pl *progressLogger) BeginSearch() {
+	pl.start = time.Now()
+}
+
+func (pl *progressLogger) EndSearch() {}
+
+func (pl *progressLogger) PrintPV(stats engine.Stats, multiPV int, score int32, pv []Move) {
+	elapsed := time.Now().Sub(pl.start)
+	if elapsed < time.Microsecond {
+		elapsed = time.Microsecond
+	}
+	info := &SearchInfo{
+		Depth:    stats.Depth,
+		SelDepth: stats.SelDepth,
+		MultiPv:  int32(multiPV),
+		Score:    newScore(score),
+		Nodes:    stats.Nodes,
+		Nps:      stats.Nodes * uint64(time.Second) / uint64(elapsed),
+		Time:     int64(elapsed / time.Millisecond),
+		Pv:       service.MovesToUCI(pv),
+	}
+
+	for len(pl.lines) < multiPV {
+		pl.lines = append(pl.lines, nil)
+	}
+	pl.lines[multiPV-1] = info
+
+	if pl.send != nil && pl.err == nil {
+		if pl.err = pl.send(info); pl.err != nil {
+			pl.stop()
+		}
+	}
+}
+
+func (pl *progressLogger) CurrMove(depth int, move Move, num int) {}
+
+// search searches the position described by req.
+// If send is not nil it is called for every principal variation found.
+// Returns the final search info with the best move set and the
+// deepest principal variations.
+func (s *Server) search(ctx context.Context, req *SearchRequest, send func(*SearchInfo) error) (*SearchInfo, []*SearchInfo, error) {
+	options, hashMB, err := s.options(req.GetOptions())
+	if err != nil {
+		return nil, nil, err
+	}
+	if req.Depth < 0 || req.MoveTime < 0 {
+		return nil, nil, status.Error(codes.InvalidArgument, "depth and move_time must be non-negative")
+	}
+	pos, err := engine.PositionFromUCI(req.Fen, req.Moves)
+	if err != nil {
+		return nil, nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	eng, err := s.get(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer s.pool.Put(eng)
+
+	if hashMB != s.pool.HashMB() {
+		eng.HashTable = engine.NewHashTable(hashMB)
+	}
+	eng.SetPosition(pos)
+	eng.Options = options
+
+	tc := engine.NewTimeControl(pos, false)
+	if req.MoveTime != 0 {
+		tc = engine.NewDeadlineTimeControl(pos, time.Duration(req.MoveTime)*time.Millisecond)
+	}
+	if req.Depth != 0 {
+		tc.Depth = req.Depth
+	}
+	log := &progressLogger{send: send, stop: tc.Stop}
+	eng.Log = log
+	tc.Start(false)
+
+	// Stop the search when the request is cancelled.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			tc.Stop()
+		case <-done:
+		}
+	}()
+
+	score, moves := eng.PlayMoves(tc, nil)
+	if ctx.Err() != nil {
+		return nil, nil, contextError(ctx)
+	}
+	if log.err != nil {
+		return nil, nil, log.err
+	}
+
+	info := &SearchInfo{}
+	if len(log.lines) != 0 && log.lines[0] != nil {
+		info = proto.Clone(log.lines[0]).(*SearchInfo)
+	}
+	info.Score = newScore(score)
+	if len(moves) >= 1 {
+		info.BestMove = moves[0].UCI()
+	}
+	if len(moves) >= 2 {
+		info.Ponder = moves[1].UCI()
+	}
+	return info, log.lines, nil
+}
+
+// Analyse searches a position and returns the principal variations.
+func (s *Server) Analyse(ctx context.Context, req *SearchRequest) (*AnalyseResponse, error) {
+	info, lines, err := s.search(ctx, req, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &AnalyseResponse{
+		Lines:    lines,
+		BestMove: info.BestMove,
+		Ponder:   info.Ponder,
+		Score:    info.Score,
+	}, nil
+}
+
+// BestMove searches a position and returns the best move.
+func (s *Server) BestMove(ctx context.Context, req *SearchRequest) (*BestMoveResponse, error) {
+	info, _, err := s.search(ctx, req, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &BestMoveResponse{
+		BestMove: info.BestMove,
+		Ponder:   info.Ponder,
+		Score:    info.Score,
+	}, nil
+}
+
+// SearchProgress searches a position and streams the principal variations.
+// The last message has the best move set.
+func (s *Server) SearchProgress(req *SearchRequest, stream Engine_SearchProgressServer) error {
+	info, _, err := s.search(stream.Context(), req, stream.Send)
+	if err != nil {
+		return err
+	}
+	return stream.Send(info)
+}
+
+// Evaluate returns the static evaluation of a position.
+func (s *Server) Evaluate(ctx context.Context, req *EvaluateRequest) (*EvaluateResponse, error) {
+	pos, err := engine.PositionFromUCI(req.Fen, req.Moves)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	eng, err := s.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer s.pool.Put(eng)
+
+	eng.SetPosition(pos)
+	score := eng.Score()
+	return &EvaluateResponse{
+		Fen:   pos.String(),
+		Score: score,
+		White: score * pos.Us().Multiplier(),
+		Phase: engine.Phase(pos),
+	}, nil
+}
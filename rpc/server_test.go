@@ -0,0 +1,175 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rpc
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"bitbucket.org/zurichess/zurichess/engine"
+	"bitbucket.org/zurichess/zurichess/internal/testdata"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newTestClient starts a server with one engine and returns a client connected to it.
+func newTestClient(t *testing.T) EngineClient {
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	RegisterEngineServer(srv, NewServer(1, 1))
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	dialer := func(context.Context, string) (net.Conn, error) { return lis.Dial() }
+	conn, err := grpc.Dial("bufnet", grpc.WithContextDialer(dialer), grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return NewEngineClient(conn)
+}
+
+func TestAnalyse(t *testing.T) {
+	client := newTestClient(t)
+	resp, err := client.Analyse(context.Background(), &SearchRequest{
+		Moves:   []string{"e2e4", "e7e5"},
+		Depth:   4,
+		Options: &Options{MultiPv: 3},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Lines) != 3 {
+		t.Fatalf("got %d lines, wanted 3", len(resp.Lines))
+	}
+	for i, line := range resp.Lines {
+		if line.MultiPv != int32(i+1) || line.Depth != 4 || len(line.Pv) == 0 {
+			t.Errorf("got unexpected line %v", line)
+		}
+	}
+	if resp.BestMove != resp.Lines[0].Pv[0] {
+		t.Errorf("got best move %s, wanted %s", resp.BestMove, resp.Lines[0].Pv[0])
+	}
+}
+
+func TestBestMove(t *testing.T) {
+	client := newTestClient(t)
+	for _, d := range testdata.MateIn1[:5] {
+		resp, err := client.BestMove(context.Background(), &SearchRequest{Fen: d.FEN, Depth: 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.EqualFold(resp.BestMove, d.BM) || resp.Score.GetMate() != 1 {
+			t.Errorf("%s: got %s %v, wanted %s mate 1", d.FEN, resp.BestMove, resp.Score, d.BM)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	client := newTestClient(t)
+	resp, err := client.Evaluate(context.Background(), &EvaluateRequest{
+		Fen: "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNB1KBNR b KQkq - 0 1", // white without queen
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Score <= 0 || resp.White != -resp.Score {
+		t.Errorf("got score %d, white %d, wanted black ahead", resp.Score, resp.White)
+	}
+}
+
+func TestSearchProgress(t *testing.T) {
+	client := newTestClient(t)
+	stream, err := client.SearchProgress(context.Background(), &SearchRequest{Depth: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var infos []*SearchInfo
+	for {
+		info, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		infos = append(infos, info)
+	}
+	if len(infos) < 2 {
+		t.Fatalf("got %d messages, wanted at least 2", len(infos))
+	}
+	for _, info := range infos[:len(infos)-1] {
+		if info.Depth > 5 || info.BestMove != "" {
+			t.Errorf("got unexpected message %v", info)
+		}
+	}
+	if last := infos[len(infos)-1]; last.BestMove == "" {
+		t.Errorf("last message %v has no best move", last)
+	}
+}
+
+func TestSearchProgressCancel(t *testing.T) {
+	client := newTestClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.SearchProgress(ctx, &SearchRequest{}) // searches until cancelled
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	for err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.Canceled {
+		t.Errorf("got error %v, wanted cancelled", err)
+	}
+
+	// The only engine must become available again.
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := client.Evaluate(ctx, &EvaluateRequest{}); err != nil {
+		t.Errorf("engine not released: %v", err)
+	}
+}
+
+func TestInvalidArgument(t *testing.T) {
+	client := newTestClient(t)
+	for _, req := range []*SearchRequest{
+		{Fen: "invalid"},
+		{Moves: []string{"e2e5"}},
+		{Depth: -1},
+		{Options: &Options{MultiPv: 100}},
+		{Options: &Options{HandicapLevel: -1}},
+		{Options: &Options{Hash: 1 << 20}},
+		{Options: &Options{Hash: 2}}, // larger than the server's table
+	} {
+		if _, err := client.BestMove(context.Background(), req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%v: got error %v, wanted invalid argument", req, err)
+		}
+	}
+}
+
+func TestHashRestored(t *testing.T) {
+	s := NewServer(1, 2)
+	req := &SearchRequest{Depth: 1, Options: &Options{Hash: 1}}
+	if _, err := s.BestMove(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	eng, err := s.get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := eng.HashTable.Size(), engine.NewHashTable(2).Size(); got != want {
+		t.Errorf("got %d hash entries after the request, wanted the server's %d", got, want)
+	}
+}
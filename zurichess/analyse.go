@@ -0,0 +1,357 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	. "bitbucket.org/zurichess/board"
+	. "bitbucket.org/zurichess/zurichess/engine"
+	"bitbucket.org/zurichess/zurichess/internal/service"
+)
+
+// csvHeader are the columns of the CSV output.
+var csvHeader = []string{"line", "id", "fen", "bestmove", "cp", "mate", "depth", "nodes", "pv"}
+
+// analyseRecord is the result of the analysis of one position.
+type analyseRecord struct {
+	Line     int        `json:"line"`         // 1-based line of the position in the input
+	ID       string     `json:"id,omitempty"` // EPD id operation
+	FEN      string     `json:"fen"`
+	BestMove string     `json:"bestmove"` // empty if the game is over
+	Score    *jsonScore `json:"score"`
+	Depth    int32      `json:"depth"`
+	Nodes    uint64     `json:"nodes"`
+	PV       []string   `json:"pv"`
+}
+
+// analyseJob is a position to analyse.
+type analyseJob struct {
+	line int
+	id   string
+	pos  *Position
+}
+
+// analyseLimits limits the search of each position.
+type analyseLimits struct {
+	depth    int32         // 0 for no limit
+	nodes    uint64        // 0 for no limit
+	moveTime time.Duration // 0 for no limit
+}
+
+// parseEPD parses a line containing a FEN or an EPD.
+// EPDs get the default half move and full move counters.
+// Returns the FEN of the position and the value of the id operation, if any.
+func parseEPD(line string) (fen, id string, err error) {
+	fields := strings.Fields(line)
+	if len(fields) < 4 {
+		return "", "", fmt.Errorf("expected a FEN or an EPD, got %q", line)
+	}
+	if len(fields) >= 6 && isNumber(fields[4]) && isNumber(fields[5]) {
+		return strings.Join(fields[:6], " "), "", nil
+	}
+
+	fen = strings.Join(fields[:4], " ") + " 0 1"
+	for _, op := range strings.Split(strings.Join(fields[4:], " "), ";") {
+		if op = strings.TrimSpace(op); strings.HasPrefix(op, "id ") {
+			id = strings.Trim(strings.TrimSpace(op[3:]), `"`)
+		}
+	}
+	return fen, id, nil
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
+
+// analysePosition searches the position of job with eng.
+// The hash table is cleared first so that the result
+// does not depend on the positions searched before.
+func analysePosition(eng *Engine, limits analyseLimits, job analyseJob) *analyseRecord {
+	eng.SetPosition(job.pos)
+	eng.HashTable.Clear()
+	tc := NewTimeControl(job.pos, false)
+	if limits.moveTime != 0 {
+		tc = NewDeadlineTimeControl(job.pos, limits.moveTime)
+	}
+	if limits.depth != 0 {
+		tc.Depth = limits.depth
+	}
+	tc.Nodes = limits.nodes
+	tc.Start(false)
+	score, pv := eng.Play(tc)
+
+	r := &analyseRecord{
+		Line:  job.line,
+		ID:    job.id,
+		FEN:   job.pos.String(),
+		Score: newScore(score),
+		Depth: eng.Stats.Depth,
+		Nodes: eng.Stats.Nodes,
+		PV:    service.MovesToUCI(pv),
+	}
+	if len(pv) != 0 {
+		r.BestMove = pv[0].UCI()
+	}
+	return r
+}
+
+// recordWriter writes analysed positions.
+type recordWriter interface {
+	write(r *analyseRecord) error
+}
+
+// csvRecordWriter writes one row per position.
+type csvRecordWriter struct {
+	w *csv.Writer
+}
+
+func newCSVRecordWriter(w io.Writer, header bool) (*csvRecordWriter, error) {
+	cw := &csvRecordWriter{w: csv.NewWriter(w)}
+	if header {
+		cw.w.Write(csvHeader)
+		cw.w.Flush()
+	}
+	return cw, cw.w.Error()
+}
+
+func (cw *csvRecordWriter) write(r *analyseRecord) error {
+	cp, mate := "", ""
+	if r.Score.CP != nil {
+		cp = strconv.Itoa(int(*r.Score.CP))
+	}
+	if r.Score.Mate != nil {
+		mate = strconv.Itoa(int(*r.Score.Mate))
+	}
+	cw.w.Write([]string{
+		strconv.Itoa(r.Line), r.ID, r.FEN, r.BestMove, cp, mate,
+		strconv.Itoa(int(r.Depth)), strconv.FormatUint(r.Nodes, 10),
+		strings.Join(r.PV, " "),
+	})
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// jsonRecordWriter writes one JSON object per line.
+type jsonRecordWriter struct {
+	enc *json.Encoder
+}
+
+func (jw *jsonRecordWriter) write(r *analyseRecord) error {
+	return jw.enc.Encode(r)
+}
+
+// readProcessed returns the input lines already analysed
+// in the output r written in format. Incomplete records,
+// e.g. from an interrupted run, are ignored.
+func readProcessed(r io.Reader, format string) map[int]bool {
+	processed := make(map[int]bool)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := 0
+		if format == "json" {
+			var rec analyseRecord
+			if json.Unmarshal(scanner.Bytes(), &rec) != nil || rec.Score == nil {
+				continue
+			}
+			line = rec.Line
+		} else {
+			row, err := csv.NewReader(strings.NewReader(scanner.Text())).Read()
+			if err != nil || len(row) != len(csvHeader) {
+				continue
+			}
+			if line, err = strconv.Atoi(row[0]); err != nil {
+				continue
+			}
+		}
+		processed[line] = true
+	}
+	return processed
+}
+
+// terminateLastLine appends a new line to f of size bytes
+// if the last record was interrupted before its end.
+func terminateLastLine(f *os.File, size int64) error {
+	if size == 0 {
+		return nil
+	}
+	last := make([]byte, 1)
+	if _, err := f.ReadAt(last, size-1); err != nil {
+		return err
+	}
+	if last[0] != '\n' {
+		_, err := f.Write([]byte{'\n'})
+		return err
+	}
+	return nil
+}
+
+// analyse analyses the positions read from in, one per line, with
+// numEngines engines in parallel and writes the results to out.
+// Lines in skip, empty lines and lines starting with # are not analysed.
+// The results are written in the order the searches finish.
+func analyse(in io.Reader, out recordWriter, limits analyseLimits, numEngines, hashSizeMB int, skip map[int]bool) error {
+	jobs := make(chan analyseJob)
+	records := make(chan *analyseRecord)
+	done := make(chan struct{})
+	for i := 0; i < numEngines; i++ {
+		go func() {
+			eng := NewEngine(nil, nil, Options{})
+			eng.HashTable = NewHashTable(hashSizeMB)
+			for job := range jobs {
+				records <- analysePosition(eng, limits, job)
+			}
+			done <- struct{}{}
+		}()
+	}
+
+	// Read the positions.
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(jobs)
+		scanner := bufio.NewScanner(in)
+		for line := 1; scanner.Scan(); line++ {
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" || strings.HasPrefix(text, "#") || skip[line] {
+				continue
+			}
+			fen, id, err := parseEPD(text)
+			if err != nil {
+				log.Printf("line %d: %v", line, err)
+				continue
+			}
+			pos, err := PositionFromFEN(fen)
+			if err != nil {
+				log.Printf("line %d: %v", line, err)
+				continue
+			}
+			jobs <- analyseJob{line: line, id: id, pos: pos}
+		}
+		readErr <- scanner.Err()
+	}()
+
+	go func() {
+		for i := 0; i < numEngines; i++ {
+			<-done
+		}
+		close(records)
+	}()
+
+	// Write the results. After a write error the remaining
+	// results are drained so the engines can finish.
+	var err error
+	for r := range records {
+		if err == nil {
+			err = out.write(r)
+		}
+	}
+	if rerr := <-readErr; err == nil {
+		err = rerr
+	}
+	return err
+}
+
+// analyseCommand analyses the positions in a FEN or EPD file.
+func analyseCommand(args []string) error {
+	fs := flag.NewFlagSet("analyse", flag.ExitOnError)
+	depth := fs.Int("depth", 0, "depth to search each position, 0 for no limit")
+	nodes := fs.Uint64("nodes", 0, "nodes to search each position, 0 for no limit")
+	moveTime := fs.Duration("movetime", 0, "time to search each position, 0 for no limit")
+	numEngines := fs.Int("engines", runtime.NumCPU(), "number of engines searching in parallel")
+	hashSizeMB := fs.Int("hash", 16, "transposition table size in MB for each engine")
+	format := fs.String("format", "csv", "output format, csv or json")
+	output := fs.String("o", "", "write the results to file instead of stdout")
+	resume := fs.Bool("resume", false, "skip the positions already in the output file")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: zurichess analyse [flags] [positions.epd]\n")
+		fmt.Fprintf(fs.Output(), "Reads the positions from stdin if no file is given.\n")
+		fs.PrintDefaults()
+	}
+	files := parseInterspersed(fs, args)
+	if len(files) > 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	if *depth == 0 && *nodes == 0 && *moveTime == 0 {
+		return errors.New("one of -depth, -nodes or -movetime is required")
+	}
+	if *numEngines < 1 {
+		return errors.New("at least one engine is required")
+	}
+	if *format != "csv" && *format != "json" {
+		return fmt.Errorf("invalid format %q, expected csv or json", *format)
+	}
+	if *resume && *output == "" {
+		return errors.New("-resume requires -o")
+	}
+
+	var in io.Reader = os.Stdin
+	if len(files) == 1 && files[0] != "-" {
+		f, err := os.Open(files[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	// When resuming the results are appended to the output.
+	var out io.Writer = os.Stdout
+	var skip map[int]bool
+	header := true
+	if *output != "" {
+		flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		if *resume {
+			flags = os.O_RDWR | os.O_CREATE | os.O_APPEND
+		}
+		f, err := os.OpenFile(*output, flags, 0644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if *resume {
+			skip = readProcessed(f, *format)
+			fi, err := f.Stat()
+			if err != nil {
+				return err
+			}
+			header = fi.Size() == 0
+			if err := terminateLastLine(f, fi.Size()); err != nil {
+				return err
+			}
+			log.Printf("resuming, %d positions already analysed", len(skip))
+		}
+		out = f
+	}
+
+	var w recordWriter = &jsonRecordWriter{enc: json.NewEncoder(out)}
+	if *format == "csv" {
+		var err error
+		if w, err = newCSVRecordWriter(out, header); err != nil {
+			return err
+		}
+	}
+	limits := analyseLimits{
+		depth:    int32(*depth),
+		nodes:    *nodes,
+		moveTime: *moveTime,
+	}
+	return analyse(in, w, limits, *numEngines, *hashSizeMB, skip)
+}
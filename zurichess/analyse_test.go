@@ -0,0 +1,91 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	. "bitbucket.org/zurichess/zurichess/internal/testdata"
+)
+
+func TestParseEPD(t *testing.T) {
+	data := []struct {
+		line, fen, id string
+	}{
+		{"8/8/8/8/8/8/8/K1k5 w - - 3 40", "8/8/8/8/8/8/8/K1k5 w - - 3 40", ""},
+		{`8/8/8/8/8/8/8/K1k5 w - - bm Kb2; id "test.1";`, "8/8/8/8/8/8/8/K1k5 w - - 0 1", "test.1"},
+		{"8/8/8/8/8/8/8/K1k5 b - -", "8/8/8/8/8/8/8/K1k5 b - - 0 1", ""},
+	}
+	for _, d := range data {
+		fen, id, err := parseEPD(d.line)
+		if err != nil || fen != d.fen || id != d.id {
+			t.Errorf("%s: got %q, %q, %v, wanted %q, %q", d.line, fen, id, err, d.fen, d.id)
+		}
+	}
+	if _, _, err := parseEPD("8/8/8 w"); err == nil {
+		t.Errorf("expected an error for a short line")
+	}
+}
+
+func TestAnalyseCSV(t *testing.T) {
+	input := strings.Join(TestFENs[:6], "\n") + "\n# comment\n\ninvalid line\n"
+	limits := analyseLimits{depth: 3}
+
+	var out bytes.Buffer
+	w, err := newCSVRecordWriter(&out, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := analyse(strings.NewReader(input), w, limits, 3, 1, nil); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(bytes.NewReader(out.Bytes())).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 7 {
+		t.Fatalf("got %d rows, wanted a header and 6 positions", len(rows))
+	}
+	for _, row := range rows[1:] {
+		if row[3] == "" || !strings.HasPrefix(row[8], row[3]) {
+			t.Errorf("got bestmove %q and pv %q", row[3], row[8])
+		}
+	}
+
+	// Resume skips the positions already analysed.
+	processed := readProcessed(bytes.NewReader(out.Bytes()), "csv")
+	if len(processed) != 6 {
+		t.Errorf("got %d processed lines, wanted 6", len(processed))
+	}
+	out.Reset()
+	w, _ = newCSVRecordWriter(&out, false)
+	if err := analyse(strings.NewReader(input), w, limits, 2, 1, processed); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("got output %q after resuming, wanted none", out.String())
+	}
+}
+
+func TestAnalyseJSON(t *testing.T) {
+	input := strings.Join(TestFENs[:4], "\n")
+	var out bytes.Buffer
+	w := &jsonRecordWriter{enc: json.NewEncoder(&out)}
+	skip := map[int]bool{2: true}
+	if err := analyse(strings.NewReader(input), w, analyseLimits{nodes: 20000}, 2, 1, skip); err != nil {
+		t.Fatal(err)
+	}
+
+	// An interrupted record is ignored.
+	out.WriteString(`{"line":2,"fen":`)
+	processed := readProcessed(bytes.NewReader(out.Bytes()), "json")
+	if len(processed) != 3 || processed[2] || !processed[1] || !processed[4] {
+		t.Errorf("got processed lines %v, wanted 1, 3 and 4", processed)
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	. "bitbucket.org/zurichess/board"
+	. "bitbucket.org/zurichess/zurichess/engine"
+	"bitbucket.org/zurichess/zurichess/pgn"
+)
+
+// Minimum score lost by a move, in centipawns, to be annotated.
+const (
+	dubiousLoss = 50  // ?!
+	mistakeLoss = 100 // ?
+	blunderLoss = 300 // ??
+)
+
+// Numeric Annotation Glyphs used by the annotator.
+const (
+	nagMistake = 2
+	nagBlunder = 4
+	nagDubious = 6
+)
+
+// lossToNAG returns the NAG for a move losing loss centipawns
+// compared to the best move, or 0 if the move is good enough.
+func lossToNAG(loss int32) int {
+	switch {
+	case loss >= blunderLoss:
+		return nagBlunder
+	case loss >= mistakeLoss:
+		return nagMistake
+	case loss >= dubiousLoss:
+		return nagDubious
+	default:
+		return 0
+	}
+}
+
+// formatScore formats score of side us from white's point of view
+// in pawns, e.g. +1.25, or as moves to mate, e.g. #-3.
+func formatScore(score int32, us Color) string {
+	if score > KnownWinScore {
+		return fmt.Sprintf("#%d", (MateScore-score+1)/2*us.Multiplier())
+	}
+	if score < KnownLossScore {
+		return fmt.Sprintf("#%d", (MatedScore-score)/2*us.Multiplier())
+	}
+	return fmt.Sprintf("%+.2f", float64(score*us.Multiplier())/100)
+}
+
+// annotator searches the positions of a game and annotates the mistakes.
+type annotator struct {
+	eng      *Engine
+	moveTime time.Duration // time to search each position, 0 for no limit
+	depth    int32         // depth to search each position, 0 for no limit
+}
+
+// search searches pos restricted to rootMoves, if not empty.
+// Returns the score from the side to move's point of view and the principal variation.
+func (a *annotator) search(pos *Position, rootMoves []Move) (int32, []Move) {
+	a.eng.SetPosition(pos)
+	tc := NewTimeControl(pos, false)
+	if a.moveTime != 0 {
+		tc = NewDeadlineTimeControl(pos, a.moveTime)
+	}
+	if a.depth != 0 {
+		tc.Depth = a.depth
+	}
+	tc.Start(false)
+	return a.eng.PlayMoves(tc, rootMoves)
+}
+
+// annotate searches every position of the main line of g.
+// Moves losing too much compared to the best move get a NAG,
+// a comment with their score and the best line as a variation.
+func (a *annotator) annotate(g *pgn.Game) error {
+	pos, err := g.Position()
+	if err != nil {
+		return err
+	}
+	for _, n := range g.Moves {
+		us := pos.Us()
+		best, pv := a.search(pos, nil)
+		if len(pv) != 0 && pv[0] != n.Move {
+			played, _ := a.search(pos, []Move{n.Move})
+			if nag := lossToNAG(best - played); nag != 0 {
+				n.NAGs = append(n.NAGs, nag)
+				n.Comment = joinComment(n.Comment, formatScore(played, us))
+
+				v := make([]*pgn.Node, len(pv))
+				for i, m := range pv {
+					v[i] = &pgn.Node{Move: m}
+				}
+				v[len(v)-1].Comment = formatScore(best, us)
+				n.Variations = append(n.Variations, v)
+			}
+		}
+		pos.DoMove(n.Move)
+	}
+	g.SetTag("Annotator", "zurichess "+buildVersion)
+	return nil
+}
+
+// joinComment appends b to the comment a.
+func joinComment(a, b string) string {
+	if a == "" {
+		return b
+	}
+	return a + " " + b
+}
+
+// parseInterspersed parses the flags in args allowing them to
+// follow the positional arguments, e.g. game.pgn -movetime 1s.
+// Returns the positional arguments.
+func parseInterspersed(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	fs.Parse(args)
+	for fs.NArg() != 0 {
+		positional = append(positional, fs.Arg(0))
+		fs.Parse(fs.Args()[1:])
+	}
+	return positional
+}
+
+// annotateCommand annotates the games in PGN files.
+func annotateCommand(args []string) error {
+	fs := flag.NewFlagSet("annotate", flag.ExitOnError)
+	moveTime := fs.Duration("movetime", time.Second, "time to search each position")
+	depth := fs.Int("depth", 0, "depth to search each position, 0 for no limit")
+	output := fs.String("o", "", "write the annotated games to file instead of stdout")
+	hashSizeMB := fs.Int("hash", DefaultHashTableSizeMB, "transposition table size in MB")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: zurichess annotate [flags] game.pgn...\n")
+		fs.PrintDefaults()
+	}
+	files := parseInterspersed(fs, args)
+	if len(files) == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	a := &annotator{
+		eng:      NewEngine(nil, nil, Options{}),
+		moveTime: *moveTime,
+		depth:    int32(*depth),
+	}
+	a.eng.HashTable = NewHashTable(*hashSizeMB)
+	w := pgn.NewWriter(out)
+	for _, file := range files {
+		if err := annotateFile(a, w, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// annotateFile annotates the games in file writing them to w.
+func annotateFile(a *annotator, w *pgn.Writer, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := pgn.NewReader(f)
+	for i := 1; ; i++ {
+		g, err := r.Read()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			log.Printf("%s: game %d: %v", file, i, err)
+			continue
+		}
+
+		log.Printf("%s: annotating game %d, %d moves", file, i, len(g.Moves))
+		if err := a.annotate(g); err != nil {
+			log.Printf("%s: game %d: %v", file, i, err)
+			continue
+		}
+		if err := w.Write(g); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "bitbucket.org/zurichess/board"
+	. "bitbucket.org/zurichess/zurichess/engine"
+	"bitbucket.org/zurichess/zurichess/pgn"
+)
+
+func TestLossToNAG(t *testing.T) {
+	data := []struct {
+		loss int32
+		nag  int
+	}{{0, 0}, {49, 0}, {50, 6}, {150, 2}, {300, 4}, {MateScore, 4}}
+	for _, d := range data {
+		if nag := lossToNAG(d.loss); nag != d.nag {
+			t.Errorf("loss %d: got %d, wanted %d", d.loss, nag, d.nag)
+		}
+	}
+}
+
+func TestFormatScore(t *testing.T) {
+	data := []struct {
+		score int32
+		us    Color
+		want  string
+	}{
+		{125, White, "+1.25"},
+		{125, Black, "-1.25"},
+		{0, Black, "+0.00"},
+		{MateScore - 5, White, "#3"},
+		{MateScore - 5, Black, "#-3"},
+		{MatedScore + 4, White, "#-2"},
+	}
+	for _, d := range data {
+		if got := formatScore(d.score, d.us); got != d.want {
+			t.Errorf("%d %v: got %s, wanted %s", d.score, d.us, got, d.want)
+		}
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	r := pgn.NewReader(strings.NewReader("1. e4 e5 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7# 1-0\n"))
+	g, err := r.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &annotator{eng: NewEngine(nil, nil, Options{}), depth: 4}
+	a.eng.HashTable = NewHashTable(1)
+	if err := a.annotate(g); err != nil {
+		t.Fatal(err)
+	}
+
+	blunder := g.Moves[5] // 3... Nf6??
+	if !reflect.DeepEqual(blunder.NAGs, []int{nagBlunder}) {
+		t.Errorf("got NAGs %v for Nf6, wanted ??", blunder.NAGs)
+	}
+	if blunder.Comment != "#1" || len(blunder.Variations) != 1 {
+		t.Errorf("got comment %q and %d variations for Nf6", blunder.Comment, len(blunder.Variations))
+	}
+	if len(g.Moves[0].NAGs) != 0 || len(g.Moves[6].NAGs) != 0 {
+		t.Errorf("good moves were annotated")
+	}
+	if g.Tag("Annotator") == "" {
+		t.Errorf("missing Annotator tag")
+	}
+}
+
+func TestParseInterspersed(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	depth := fs.Int("depth", 0, "")
+	args := parseInterspersed(fs, []string{"a.pgn", "-depth", "3", "b.pgn"})
+	if *depth != 3 || !reflect.DeepEqual(args, []string{"a.pgn", "b.pgn"}) {
+		t.Errorf("got depth %d and args %v", *depth, args)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	. "bitbucket.org/zurichess/board"
+	. "bitbucket.org/zurichess/zurichess/engine"
+)
+
+// figureToSymbol maps figures to their symbol on the ASCII board.
+var figureToSymbol = [...]string{".", "p", "n", "b", "r", "q", "k"}
+
+// printBoard prints pos as an ASCII board with white at the bottom.
+func printBoard(w io.Writer, pos *Position) {
+	fmt.Fprintf(w, "   +-----------------+\n")
+	for r := 7; r >= 0; r-- {
+		fmt.Fprintf(w, " %d |", r+1)
+		for f := 0; f < 8; f++ {
+			pi := pos.Get(RankFile(r, f))
+			symbol := figureToSymbol[pi.Figure()]
+			if pi.Color() == White {
+				symbol = strings.ToUpper(symbol)
+			}
+			fmt.Fprintf(w, " %s", symbol)
+		}
+		fmt.Fprintf(w, " |\n")
+	}
+	fmt.Fprintf(w, "   +-----------------+\n")
+	fmt.Fprintf(w, "     a b c d e f g h\n")
+}
+
+// printPosition prints the position of eng: the board, the FEN,
+// the Zobrist key, the static evaluation and the legal moves in SAN.
+func printPosition(w io.Writer, eng *Engine) {
+	pos := eng.Position
+	printBoard(w, pos)
+	fmt.Fprintf(w, "fen %s\n", pos.String())
+	fmt.Fprintf(w, "key %016x\n", pos.Zobrist())
+
+	score := eng.Score()
+	fmt.Fprintf(w, "eval %d (white %d) phase %d\n", score, score*pos.Us().Multiplier(), Phase(pos))
+
+	moves := LegalMoves(pos)
+	san := make([]string, len(moves))
+	for i, m := range moves {
+		san[i] = MoveToSAN(pos, m)
+	}
+	sort.Strings(san)
+	fmt.Fprintf(w, "moves %d: %s\n", len(san), strings.Join(san, " "))
+}
@@ -0,0 +1,59 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDisplay(t *testing.T) {
+	buf := &bytes.Buffer{}
+	uci := NewUCI(buf)
+	for _, line := range []string{
+		"position startpos moves e2e4 e7e5 g1f3",
+		"d",
+	} {
+		if err := uci.Execute(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		" 8 | r n b q k b n r |\n",
+		" 3 | . . . . . N . . |\n",
+		"fen rnbqkbnr/pppp1ppp/8/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R b KQkq - 1 2\n",
+		"key ",
+		"eval ",
+		"moves 29: ",
+		" Nc6 ",
+		" Qh4 ",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+}
+
+func TestShowSAN(t *testing.T) {
+	buf := &bytes.Buffer{}
+	uci := NewUCI(buf)
+	for _, line := range []string{
+		"setoption name Show SAN value true",
+		"position fen 6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1",
+		"go depth 2",
+	} {
+		if err := uci.Execute(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	uci.Execute("stop") // waits for the search to finish
+
+	if out := buf.String(); !strings.Contains(out, "info string multipv 1 san Ra8#\n") {
+		t.Errorf("missing san principal variation in\n%s", out)
+	}
+}
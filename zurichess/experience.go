@@ -0,0 +1,177 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// experience.go records the games played over UCI in the experience
+// file and implements the experience command which inspects and
+// prunes experience files.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+
+	. "bitbucket.org/zurichess/board"
+	. "bitbucket.org/zurichess/zurichess/engine"
+)
+
+const (
+	maxExperienceSize = 1 << 22
+	// resultMargin is the score of the last search, in centipawns,
+	// from which an unfinished game is considered won.
+	resultMargin = 200
+)
+
+// gameSearch is a search of the current game.
+type gameSearch struct {
+	key   uint64 // Zobrist key of the root position
+	us    Color  // side to move at root
+	move  Move   // best move
+	score int32  // from us POV
+	depth int32  // completed depth
+}
+
+// setExperienceFile loads the experience from the file name.
+// The experience is put in the hash table. An empty name disables it.
+func (uci *UCI) setExperienceFile(name string) error {
+	uci.experience, uci.experienceFile, uci.game = nil, "", nil
+	if name == "" {
+		return nil
+	}
+	x, err := ReadExperienceFile(name, uci.experienceSize)
+	if err != nil {
+		return err
+	}
+	uci.experience, uci.experienceFile = x, name
+	uci.mergeExperience()
+	return nil
+}
+
+// mergeExperience puts the experience in the hash table.
+func (uci *UCI) mergeExperience() {
+	if uci.experience != nil {
+		uci.experience.Merge(uci.hashTable())
+	}
+}
+
+// recordSearch remembers the last search for the experience.
+func (uci *UCI) recordSearch(score int32, moves []Move) {
+	if uci.experience == nil || len(moves) == 0 || uci.Engine.Stats.Depth <= 0 {
+		return
+	}
+	pos := uci.Engine.Position
+	uci.game = append(uci.game, gameSearch{
+		key:   pos.Zobrist(),
+		us:    pos.Us(),
+		move:  moves[0],
+		score: score,
+		depth: uci.Engine.Stats.Depth,
+	})
+}
+
+// endGame records the searches of the current game with
+// the game's result and saves the experience file.
+func (uci *UCI) endGame() error {
+	if uci.experience == nil || len(uci.game) == 0 {
+		return nil
+	}
+	result := gameResult(uci.Engine.Position, &uci.game[len(uci.game)-1])
+	for _, s := range uci.game {
+		uci.experience.Record(s.key, s.move, s.score, s.depth, result*int(s.us.Multiplier()))
+	}
+	uci.game = nil
+	uci.experience.MaxSize = uci.experienceSize
+	uci.experience.Prune(0)
+	return uci.experience.WriteFile(uci.experienceFile)
+}
+
+// gameResult returns the result of the game from White's POV given
+// the last position and the last search. If the game is not over
+// the result is guessed from the score of the last search.
+//
+// UCI doesn't tell the engine how the game ended, so pos is the last
+// position received with the position command. That is the position
+// of the engine's last move, and rarely the final position of the game,
+// so most results are guessed.
+func gameResult(pos *Position, last *gameSearch) int {
+	if !pos.HasLegalMoves() {
+		if pos.IsChecked(pos.Us()) {
+			return -int(pos.Us().Multiplier())
+		}
+		return 0
+	}
+	if pos.InsufficientMaterial() || pos.FiftyMoveRule() || pos.ThreeFoldRepetition() >= 3 {
+		return 0
+	}
+	if last.score >= resultMargin {
+		return int(last.us.Multiplier())
+	}
+	if last.score <= -resultMargin {
+		return -int(last.us.Multiplier())
+	}
+	return 0
+}
+
+// experienceCommand prints and prunes an experience file.
+func experienceCommand(args []string) error {
+	fs := flag.NewFlagSet("experience", flag.ExitOnError)
+	list := fs.Bool("list", false, "print all entries")
+	size := fs.Int("size", 0, "keep at most size positions; if 0, don't limit the size")
+	minDepth := fs.Int("mindepth", 0, "remove the positions searched shallower than mindepth")
+	output := fs.String("o", "", "write the pruned experience to file; if empty, overwrite the input")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: zurichess experience [flags] experience.bin\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	x, err := ReadExperience(bufio.NewReader(f), maxExperienceSize)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	if *list {
+		fmt.Fprintf(w, "%-16s %-5s %5s %6s %5s %5s %6s\n", "key", "move", "depth", "score", "wins", "draws", "losses")
+		for _, e := range x.Entries() {
+			fmt.Fprintf(w, "%016x %-5s %5d %6d %5d %5d %6d\n",
+				e.Key, e.Move.UCI(), e.Depth, e.Score, e.Wins, e.Draws, e.Losses)
+		}
+	}
+
+	before := x.Len()
+	if *size > 0 {
+		x.MaxSize = *size
+	}
+	x.Prune(*minDepth)
+
+	// The outcomes are counted once for each position of a game.
+	wins, draws, losses := 0, 0, 0
+	for _, e := range x.Entries() {
+		wins, draws, losses = wins+int(e.Wins), draws+int(e.Draws), losses+int(e.Losses)
+	}
+	fmt.Fprintf(w, "positions %d pruned %d outcomes +%d =%d -%d\n",
+		x.Len(), before-x.Len(), wins, draws, losses)
+
+	if x.Len() == before && *output == "" {
+		return nil
+	}
+	name := *output
+	if name == "" {
+		name = fs.Arg(0)
+	}
+	return x.WriteFile(name)
+}
@@ -0,0 +1,49 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+
+	"bitbucket.org/zurichess/zurichess/rpc"
+	"google.golang.org/grpc"
+)
+
+// grpcCommand runs the gRPC engine service until interrupted.
+func grpcCommand(args []string) error {
+	fs := flag.NewFlagSet("grpc", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:8082", "address to listen on")
+	numEngines := fs.Int("engines", runtime.NumCPU(), "number of engines searching in parallel")
+	hashSizeMB := fs.Int("hash", 16, "default and maximum transposition table size in MB for each engine")
+	fs.Parse(args)
+	if *numEngines < 1 {
+		return errors.New("at least one engine is required")
+	}
+
+	lis, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	srv := grpc.NewServer()
+	rpc.RegisterEngineServer(srv, rpc.NewServer(*numEngines, *hashSizeMB))
+
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		<-sig
+		log.Println("shutting down")
+		srv.GracefulStop()
+	}()
+
+	log.Printf("listening on %s with %d engines", *addr, *numEngines)
+	return srv.Serve(lis)
+}
@@ -0,0 +1,102 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"time"
+
+	. "bitbucket.org/zurichess/zurichess/engine"
+)
+
+// maxLag is the maximum lag considered a valid measurement.
+const maxLag = 10 * time.Second
+
+// lagMeter estimates the lag between sending bestmove and the GUI
+// stopping our clock, e.g. because of slow pipes or remote GUIs.
+//
+// The lag is measured by comparing the time the GUI charged us
+// for the previous move with the time we think we used.
+type lagMeter struct {
+	ply     int           // ply of the last search, -1 if unknown
+	mode    ClockMode     // clock mode during the last search
+	time    time.Duration // our remaining time at the start of the last search
+	inc     time.Duration // our increment during the last search
+	delay   time.Duration // our delay during the last search
+	start   time.Time     // when go was received
+	elapsed time.Duration // time between go and bestmove
+	lag     time.Duration // estimated lag
+}
+
+func newLagMeter() *lagMeter {
+	return &lagMeter{ply: -1}
+}
+
+// reset forgets the previous measurements, e.g. at the start of a new game.
+func (lm *lagMeter) reset() {
+	*lm = lagMeter{ply: -1}
+}
+
+// begin is called when a new search starts at ply with the clock mode
+// and our remaining time, increment and delay.
+// Returns the latest lag sample or 0 if no sample is available.
+func (lm *lagMeter) begin(ply int, mode ClockMode, remaining, inc, delay time.Duration) time.Duration {
+	sample := time.Duration(0)
+	if lm.ply != -1 && ply == lm.ply+2 && lm.elapsed != 0 {
+		// time charged by the GUI minus time we used.
+		sample = lm.charged(remaining) - lm.elapsed
+		if 0 < sample && sample < maxLag {
+			// Rise quickly, decay slowly.
+			lm.lag = (3*lm.lag + sample) / 4
+			if lm.lag < sample {
+				lm.lag = sample
+			}
+		} else {
+			sample = 0
+		}
+	}
+
+	lm.ply, lm.mode = ply, mode
+	lm.time, lm.inc, lm.delay = remaining, inc, delay
+	lm.start = time.Now()
+	lm.elapsed = 0
+	return sample
+}
+
+// charged returns the time the GUI charged us for the last search
+// given our remaining time now, or 0 if it cannot be known.
+func (lm *lagMeter) charged(remaining time.Duration) time.Duration {
+	charged := lm.time + lm.inc - remaining
+	switch lm.mode {
+	case DelayClock, BronsteinClock:
+		// The delay is not charged, so the time used
+		// is known only if it exceeded the delay.
+		if charged <= 0 {
+			return 0
+		}
+		return charged + lm.delay
+	case HourglassClock:
+		// The opponent's move added its time to our clock.
+		return 0
+	}
+	return charged
+}
+
+// skip is called when a new search starts without a clock.
+func (lm *lagMeter) skip() {
+	lm.ply = -1
+}
+
+// end is called when bestmove is sent.
+func (lm *lagMeter) end() {
+	lm.elapsed = time.Now().Sub(lm.start)
+}
+
+// overhead returns the overhead to use given the configured overhead.
+func (lm *lagMeter) overhead(configured time.Duration) time.Duration {
+	if emergency := lm.lag * 3 / 2; emergency > configured {
+		return emergency
+	}
+	return configured
+}
@@ -0,0 +1,76 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	. "bitbucket.org/zurichess/zurichess/engine"
+)
+
+func TestLagMeter(t *testing.T) {
+	const ms = time.Millisecond
+	data := []struct {
+		mode      ClockMode
+		time, inc time.Duration // remaining time and increment before the move
+		delay     time.Duration
+		elapsed   time.Duration // time we used for the move
+		remaining time.Duration // remaining time after the move
+		ply       int           // ply of the next search
+		want      time.Duration // lag sample
+	}{
+		{FischerClock, 1000 * ms, 100 * ms, 0, 300 * ms, 750 * ms, 12, 50 * ms},
+		{FischerClock, 1000 * ms, 0, 0, 300 * ms, 700 * ms, 12, 0},   // no lag
+		{FischerClock, 1000 * ms, 0, 0, 300 * ms, 650 * ms, 11, 0},   // not our next move
+		{FischerClock, 1000 * ms, 0, 0, 300 * ms, 1000 * ms, 12, 0},  // negative lag
+		{FischerClock, 100000 * ms, 0, 0, 1 * ms, 50000 * ms, 12, 0}, // larger than maxLag
+		{DelayClock, 1000 * ms, 0, 200 * ms, 300 * ms, 850 * ms, 12, 50 * ms},
+		{DelayClock, 1000 * ms, 0, 500 * ms, 300 * ms, 1000 * ms, 12, 0}, // within the delay
+		{BronsteinClock, 1000 * ms, 0, 200 * ms, 300 * ms, 850 * ms, 12, 50 * ms},
+		{BronsteinClock, 1000 * ms, 0, 500 * ms, 300 * ms, 1000 * ms, 12, 0},
+		{HourglassClock, 1000 * ms, 0, 0, 300 * ms, 600 * ms, 12, 0},
+	}
+	for i, d := range data {
+		lm := newLagMeter()
+		if got := lm.begin(10, d.mode, d.time, d.inc, d.delay); got != 0 {
+			t.Errorf("#%d: got sample %v for the first search, wanted 0", i, got)
+		}
+		lm.end()
+		lm.elapsed = d.elapsed
+		if got := lm.begin(d.ply, d.mode, d.remaining, d.inc, d.delay); got != d.want {
+			t.Errorf("#%d: got sample %v, wanted %v", i, got, d.want)
+		}
+		want := 20 * ms
+		if d.want*3/2 > want {
+			want = d.want * 3 / 2
+		}
+		if got := lm.overhead(20 * ms); got != want {
+			t.Errorf("#%d: got overhead %v, wanted %v", i, got, want)
+		}
+	}
+}
+
+func TestLagMeterDecay(t *testing.T) {
+	const ms = time.Millisecond
+	lm := newLagMeter()
+	remaining := 10000 * ms
+	for i, lag := range []time.Duration{80 * ms, 20 * ms} {
+		lm.begin(2*i, FischerClock, remaining, 0, 0)
+		lm.end()
+		lm.elapsed = 100 * ms
+		remaining -= lm.elapsed + lag
+	}
+	lm.begin(4, FischerClock, remaining, 0, 0)
+	// The lag rises to the first sample and decays towards the second.
+	if got, want := lm.overhead(0), (3*80*ms+20*ms)/4*3/2; got != want {
+		t.Errorf("got overhead %v, wanted %v", got, want)
+	}
+
+	lm.reset()
+	if got := lm.overhead(20 * ms); got != 20*ms {
+		t.Errorf("got overhead %v after reset, wanted 20ms", got)
+	}
+}
@@ -0,0 +1,334 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// serve.go implements an HTTP/JSON analysis server.
+//
+// The server handles the following requests:
+//
+//	POST /analyse searches a position. The request body is an analyseRequest.
+//	The response streams the search progress as JSON lines or as
+//	server-sent events if the client accepts text/event-stream.
+//
+//	GET /eval?fen=FEN returns the static evaluation of a position.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	. "bitbucket.org/zurichess/board"
+	. "bitbucket.org/zurichess/zurichess/engine"
+	"bitbucket.org/zurichess/zurichess/internal/service"
+)
+
+const maxRequestSize = 1 << 20
+
+// analyseRequest is the body of a POST /analyse request.
+type analyseRequest struct {
+	FEN      string   `json:"fen"`      // position to analyse, defaults to the start position
+	Moves    []string `json:"moves"`    // moves in UCI format played from FEN
+	Depth    int32    `json:"depth"`    // maximum search depth, 0 for no limit
+	MoveTime int64    `json:"movetime"` // search time in milliseconds, 0 for no limit
+	MultiPV  int      `json:"multipv"`  // number of principal variations, defaults to 1
+}
+
+// analyseEvent is an event streamed in response to a POST /analyse request.
+type analyseEvent struct {
+	Event    string     `json:"event"` // pv, bestmove or error
+	Depth    int32      `json:"depth,omitempty"`
+	SelDepth int32      `json:"seldepth,omitempty"`
+	MultiPV  int        `json:"multipv,omitempty"`
+	Score    *jsonScore `json:"score,omitempty"`
+	Nodes    uint64     `json:"nodes,omitempty"`
+	NPS      uint64     `json:"nps,omitempty"`
+	Time     int64      `json:"time,omitempty"` // milliseconds since the search started
+	PV       []string   `json:"pv,omitempty"`
+	BestMove string     `json:"bestmove,omitempty"`
+	Ponder   string     `json:"ponder,omitempty"`
+	Error    string     `json:"error,omitempty"`
+}
+
+// evalResponse is the response to a GET /eval request.
+type evalResponse struct {
+	FEN   string `json:"fen"`
+	Score int32  `json:"score"` // score in centipawns from side to move's POV
+	White int32  `json:"white"` // score in centipawns from white's POV
+	Phase int32  `json:"phase"` // 0 is opening, 256 is late end game
+}
+
+// jsonScore is a search score in centipawns or moves to mate.
+type jsonScore struct {
+	CP   *int32 `json:"cp,omitempty"`
+	Mate *int32 `json:"mate,omitempty"`
+}
+
+// newScore converts a search score to a jsonScore.
+func newScore(s int32) *jsonScore {
+	if mate, ok := service.MateIn(s); ok {
+		return &jsonScore{Mate: &mate}
+	}
+	return &jsonScore{CP: &s}
+}
+
+// eventWriter writes events as JSON lines or server-sent events.
+type eventWriter struct {
+	w   io.Writer
+	sse bool  // true to write server-sent events
+	err error // first write error
+}
+
+func (ew *eventWriter) write(ev *analyseEvent) {
+	if ew.err != nil {
+		return
+	}
+	data, err := json.Marshal(ev)
+	if err != nil {
+		ew.err = err
+		return
+	}
+	if ew.sse {
+		_, ew.err = fmt.Fprintf(ew.w, "event: %s\ndata: %s\n\n", ev.Event, data)
+	} else {
+		_, ew.err = fmt.Fprintf(ew.w, "%s\n", data)
+	}
+	if f, ok := ew.w.(http.Flusher); ok && ew.err == nil {
+		f.Flush()
+	}
+}
+
+// streamLogger streams the search progress to an eventWriter.
+type streamLogger struct {
+	ew    *eventWriter
+	start time.Time
+}
+
+func (sl *streamLogger) BeginSearch() {
+	sl.start = time.Now()
+}
+
+func (sl *streamLogger) EndSearch() {}
+
+func (sl *streamLogger) PrintPV(stats Stats, multiPV int, s int32, pv []Move) {
+	elapsed := maxDuration(time.Now().Sub(sl.start), time.Microsecond)
+	ev := &analyseEvent{
+		Event:    "pv",
+		Depth:    stats.Depth,
+		SelDepth: stats.SelDepth,
+		MultiPV:  multiPV,
+		Score:    newScore(s),
+		Nodes:    stats.Nodes,
+		NPS:      stats.Nodes * uint64(time.Second) / uint64(elapsed),
+		Time:     int64(elapsed / time.Millisecond),
+		PV:       service.MovesToUCI(pv),
+	}
+	sl.ew.write(ev)
+}
+
+func (sl *streamLogger) CurrMove(depth int, move Move, num int) {}
+
+// server is an HTTP/JSON analysis server.
+type server struct {
+	pool    *service.Pool
+	timeout time.Duration // maximum duration of a request
+}
+
+func newServer(numEngines, hashSizeMB int, timeout time.Duration) *server {
+	return &server{
+		pool:    service.NewPool(numEngines, hashSizeMB),
+		timeout: timeout,
+	}
+}
+
+// handler returns the handler serving the server's requests.
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/analyse", s.analyse)
+	mux.HandleFunc("/eval", s.eval)
+	return mux
+}
+
+// httpError replies to the request with an error encoded in JSON.
+func httpError(w http.ResponseWriter, err error, code int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(&analyseEvent{Event: "error", Error: err.Error()})
+}
+
+func (s *server) analyse(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httpError(w, fmt.Errorf("method %s not allowed", r.Method), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req analyseRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode(&req); err != nil {
+		httpError(w, err, http.StatusBadRequest)
+		return
+	}
+	if req.MultiPV == 0 {
+		req.MultiPV = 1
+	}
+	if req.MultiPV < 1 || req.MultiPV > maxMultiPV {
+		httpError(w, fmt.Errorf("multipv must be between 1 and %d", maxMultiPV), http.StatusBadRequest)
+		return
+	}
+	if req.Depth < 0 || req.MoveTime < 0 {
+		httpError(w, errors.New("depth and movetime must be non-negative"), http.StatusBadRequest)
+		return
+	}
+	pos, err := PositionFromUCI(req.FEN, req.Moves)
+	if err != nil {
+		httpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.timeout)
+	defer cancel()
+	eng, err := s.pool.Get(ctx)
+	if err != nil {
+		httpError(w, errors.New("no engine available"), http.StatusServiceUnavailable)
+		return
+	}
+	defer s.pool.Put(eng)
+
+	ew := &eventWriter{w: w}
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		ew.sse = true
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+
+	eng.SetPosition(pos)
+	eng.Options = Options{AnalyseMode: true, MultiPV: req.MultiPV}
+	eng.Log = &streamLogger{ew: ew}
+
+	tc := NewTimeControl(pos, false)
+	if req.MoveTime != 0 {
+		tc = NewDeadlineTimeControl(pos, time.Duration(req.MoveTime)*time.Millisecond)
+	}
+	if req.Depth != 0 {
+		tc.Depth = req.Depth
+	}
+	tc.Start(false)
+
+	// Stop the search when the request is cancelled or times out.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			tc.Stop()
+		case <-done:
+		}
+	}()
+
+	sc, moves := eng.PlayMoves(tc, nil)
+	ev := &analyseEvent{Event: "bestmove", Score: newScore(sc)}
+	if len(moves) >= 1 {
+		ev.BestMove = moves[0].UCI()
+	}
+	if len(moves) >= 2 {
+		ev.Ponder = moves[1].UCI()
+	}
+	ew.write(ev)
+}
+
+func (s *server) eval(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpError(w, fmt.Errorf("method %s not allowed", r.Method), http.StatusMethodNotAllowed)
+		return
+	}
+	var moves []string
+	if m := r.URL.Query().Get("moves"); m != "" {
+		moves = strings.Fields(m)
+	}
+	pos, err := PositionFromUCI(r.URL.Query().Get("fen"), moves)
+	if err != nil {
+		httpError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.timeout)
+	defer cancel()
+	eng, err := s.pool.Get(ctx)
+	if err != nil {
+		httpError(w, errors.New("no engine available"), http.StatusServiceUnavailable)
+		return
+	}
+	defer s.pool.Put(eng)
+
+	eng.SetPosition(pos)
+	sc := eng.Score()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&evalResponse{
+		FEN:   pos.String(),
+		Score: sc,
+		White: sc * pos.Us().Multiplier(),
+		Phase: Phase(pos),
+	})
+}
+
+// serveCommand runs the HTTP/JSON analysis server until interrupted.
+func serveCommand(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:8080", "address to listen on")
+	numEngines := fs.Int("engines", runtime.NumCPU(), "number of engines searching in parallel")
+	hashSizeMB := fs.Int("hash", 16, "transposition table size in MB for each engine")
+	timeout := fs.Duration("timeout", time.Minute, "maximum duration of a request")
+	fs.Parse(args)
+	if *numEngines < 1 {
+		return errors.New("at least one engine is required")
+	}
+
+	s := newServer(*numEngines, *hashSizeMB, *timeout)
+
+	// Searches are cancelled through the base context if
+	// the server cannot shut down within the timeout.
+	base, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	srv := &http.Server{
+		Addr:        *addr,
+		Handler:     s.handler(),
+		BaseContext: func(_ net.Listener) context.Context { return base },
+	}
+
+	idle := make(chan struct{})
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		<-sig
+		log.Println("shutting down")
+
+		ctx, cancelShutdown := context.WithTimeout(context.Background(), *timeout)
+		defer cancelShutdown()
+		if err := srv.Shutdown(ctx); err != nil {
+			cancel()
+			srv.Close()
+		}
+		close(idle)
+	}()
+
+	log.Printf("listening on %s with %d engines", *addr, *numEngines)
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	<-idle
+	return nil
+}
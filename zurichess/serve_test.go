@@ -0,0 +1,132 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	s := newServer(2, 1, 10*time.Second)
+	ts := httptest.NewServer(s.handler())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestServeAnalyse(t *testing.T) {
+	ts := newTestServer(t)
+	body := `{"moves": ["e2e4", "e7e5"], "depth": 4, "multipv": 2}`
+	resp, err := http.Post(ts.URL+"/analyse", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got status %s", resp.Status)
+	}
+
+	var events []analyseEvent
+	scan := bufio.NewScanner(resp.Body)
+	for scan.Scan() {
+		var ev analyseEvent
+		if err := json.Unmarshal(scan.Bytes(), &ev); err != nil {
+			t.Fatalf("cannot parse %q: %v", scan.Text(), err)
+		}
+		events = append(events, ev)
+	}
+
+	if len(events) == 0 {
+		t.Fatalf("no events received")
+	}
+	last := events[len(events)-1]
+	if last.Event != "bestmove" || last.BestMove == "" {
+		t.Errorf("got last event %+v, wanted bestmove", last)
+	}
+	multiPV := 0
+	for _, ev := range events[:len(events)-1] {
+		if ev.Event != "pv" || ev.Depth > 4 || ev.Score == nil {
+			t.Errorf("got unexpected event %+v", ev)
+		}
+		if ev.MultiPV > multiPV {
+			multiPV = ev.MultiPV
+		}
+	}
+	if multiPV != 2 {
+		t.Errorf("got %d pvs, wanted 2", multiPV)
+	}
+}
+
+func TestServeAnalyseSSE(t *testing.T) {
+	ts := newTestServer(t)
+	req, _ := http.NewRequest("POST", ts.URL+"/analyse", strings.NewReader(`{"movetime": 100}`))
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("got content type %s", ct)
+	}
+	scan := bufio.NewScanner(resp.Body)
+	event := ""
+	for scan.Scan() {
+		if strings.HasPrefix(scan.Text(), "event: ") {
+			event = strings.TrimPrefix(scan.Text(), "event: ")
+		}
+	}
+	if event != "bestmove" {
+		t.Errorf("got last event %q, wanted bestmove", event)
+	}
+}
+
+func TestServeAnalyseBadRequest(t *testing.T) {
+	ts := newTestServer(t)
+	for _, body := range []string{
+		`{`,
+		`{"fen": "invalid"}`,
+		`{"moves": ["e2e5"]}`,
+		`{"multipv": 100}`,
+		`{"depth": -1}`,
+	} {
+		resp, err := http.Post(ts.URL+"/analyse", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: got status %s, wanted bad request", body, resp.Status)
+		}
+	}
+}
+
+func TestServeEval(t *testing.T) {
+	ts := newTestServer(t)
+	fen := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNB1KBNR w KQkq - 0 1" // white without queen
+	resp, err := http.Get(ts.URL + "/eval?fen=" + url.QueryEscape(fen))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var ev evalResponse
+	if err := json.NewDecoder(resp.Body).Decode(&ev); err != nil {
+		t.Fatal(err)
+	}
+	if ev.FEN != fen {
+		t.Errorf("got fen %s, wanted %s", ev.FEN, fen)
+	}
+	if ev.Score >= 0 || ev.White != ev.Score {
+		t.Errorf("got score %d, white %d, wanted negative scores", ev.Score, ev.White)
+	}
+}
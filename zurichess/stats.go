@@ -0,0 +1,40 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io"
+
+	. "bitbucket.org/zurichess/zurichess/engine"
+)
+
+// percent returns a as a percentage of b.
+func percent(a, b uint64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a) * 100 / float64(b)
+}
+
+// printStats prints the search statistics as info strings to w.
+func printStats(w io.Writer, s *Stats) {
+	fmt.Fprintf(w, "info string stats depth %d seldepth %d nodes %d qnodes %d (%.1f%%)\n",
+		s.Depth, s.SelDepth, s.Nodes, s.QNodes, percent(s.QNodes, s.Nodes))
+	fmt.Fprintf(w, "info string stats cutoffs %d first move %d (%.1f%%)\n",
+		s.CutOffs, s.FirstMoveCutOffs, percent(s.FirstMoveCutOffs, s.CutOffs))
+	fmt.Fprintf(w, "info string stats nullmove tries %d cutoffs %d (%.1f%%)\n",
+		s.NullMoveTries, s.NullMoveCutOffs, percent(s.NullMoveCutOffs, s.NullMoveTries))
+	fmt.Fprintf(w, "info string stats razorings %d futility prunes %d\n",
+		s.Razorings, s.FutilityPrunes)
+	fmt.Fprintf(w, "info string stats lmr reductions %d researches %d (%.1f%%)\n",
+		s.LMRReductions, s.LMRResearches, percent(s.LMRResearches, s.LMRReductions))
+	fmt.Fprintf(w, "info string stats hash hits %d (%.1f%%) cutoffs exact %d lower %d upper %d\n",
+		s.CacheHit, percent(s.CacheHit, s.CacheHit+s.CacheMiss),
+		s.HashExactCutOffs, s.HashLowerCutOffs, s.HashUpperCutOffs)
+	fmt.Fprintf(w, "info string stats evaluations %d cache hits %d (%.1f%%) lazy %d\n",
+		s.Evaluations, s.EvalCacheHits, percent(s.EvalCacheHits, s.Evaluations+s.EvalCacheHits),
+		s.LazyEvaluations)
+}
@@ -0,0 +1,145 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// tree.go implements the tree command which prints the search tree
+// recorded with the -trace flag.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	. "bitbucket.org/zurichess/zurichess/engine"
+)
+
+// searchTree is a search tree loaded from a JSON trace.
+type searchTree struct {
+	nodes    map[int]*TraceNode
+	children map[int][]int // children ids in search order
+	roots    []int         // root ids in search order
+}
+
+// readSearchTree reads a trace written by engine.Tracer in JSON format.
+func readSearchTree(r io.Reader) (*searchTree, error) {
+	st := &searchTree{
+		nodes:    make(map[int]*TraceNode),
+		children: make(map[int][]int),
+	}
+
+	scan := bufio.NewScanner(r)
+	scan.Buffer(nil, 1<<20)
+	for scan.Scan() {
+		node := &TraceNode{}
+		if err := json.Unmarshal(scan.Bytes(), node); err != nil {
+			return nil, err
+		}
+		st.nodes[node.ID] = node
+		if node.Parent == -1 {
+			st.roots = append(st.roots, node.ID)
+		} else {
+			st.children[node.Parent] = append(st.children[node.Parent], node.ID)
+		}
+	}
+	if err := scan.Err(); err != nil {
+		return nil, err
+	}
+
+	// Nodes are written in post-order, but ids are in pre-order.
+	sort.Ints(st.roots)
+	for _, c := range st.children {
+		sort.Ints(c)
+	}
+	return st, nil
+}
+
+// print prints the subtree rooted at id up to maxPly plies below it.
+func (st *searchTree) print(w io.Writer, id int, indent, maxPly int) {
+	node := st.nodes[id]
+	move := node.Move
+	if move == "" {
+		move = "root"
+	}
+	fmt.Fprintf(w, "%s%s depth %d window [%d, %d] score %d",
+		strings.Repeat("  ", indent), move, node.Depth, node.Alpha, node.Beta, node.Score)
+	if node.Reason != "" {
+		fmt.Fprintf(w, " %s", node.Reason)
+	}
+	if node.Hash {
+		fmt.Fprintf(w, " tt")
+	}
+	if node.QS {
+		fmt.Fprintf(w, " qs")
+	}
+	fmt.Fprintf(w, "\n")
+
+	if maxPly > 0 {
+		for _, c := range st.children[id] {
+			st.print(w, c, indent+1, maxPly-1)
+		}
+	}
+}
+
+// treeCommand prints the subtree under a root move.
+func treeCommand(args []string) error {
+	fs := flag.NewFlagSet("tree", flag.ExitOnError)
+	move := fs.String("move", "", "root move in UCI format; if empty, print all root moves")
+	depth := fs.Int("depth", -1, "iteration depth; if negative, use the last iteration")
+	maxPly := fs.Int("maxply", 64, "maximum plies to print below the root move")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: zurichess tree [flags] trace.json\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	st, err := readSearchTree(f)
+	if err != nil {
+		return err
+	}
+
+	// Find the last root matching the requested depth.
+	root := -1
+	for _, id := range st.roots {
+		if *depth < 0 || st.nodes[id].Depth == int32(*depth) {
+			root = id
+		}
+	}
+	if root == -1 {
+		return fmt.Errorf("no search at depth %d found", *depth)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	if *move == "" {
+		st.print(w, root, 0, *maxPly+1)
+		return nil
+	}
+
+	found := false
+	for _, c := range st.children[root] {
+		if st.nodes[c].Move == *move {
+			st.print(w, c, 0, *maxPly)
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("move %s not found at root", *move)
+	}
+	return nil
+}
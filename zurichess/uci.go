@@ -11,6 +11,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"regexp"
@@ -20,23 +21,35 @@ import (
 
 	. "bitbucket.org/zurichess/board"
 	. "bitbucket.org/zurichess/zurichess/engine"
+	"bitbucket.org/zurichess/zurichess/internal/service"
 )
 
 var errQuit = errors.New("quit")
 
 const (
-	maxMultiPV       = 16
-	maxHandicapLevel = 20
+	maxMultiPV         = service.MaxMultiPV
+	maxHandicapLevel   = service.MaxHandicapLevel
+	maxContempt        = 100
+	maxSeed            = 1<<31 - 1
+	maxMoveOverhead    = 5 * time.Second
+	maxMinThinkingTime = 5 * time.Second
+	maxMovesToGo       = 100
+	maxHashMB          = 65536
+	maxMateNodes       = 1 << 21 // limits go mate to a few hundred MB
+	mateFallbackDepth  = 4       // depth of the search when go mate finds no mate
 )
 
 // uciLogger outputs search in uci format.
 type uciLogger struct {
-	start time.Time
-	buf   *bytes.Buffer
+	start   time.Time
+	buf     *bytes.Buffer
+	out     io.Writer
+	eng     *Engine // engine whose searches are logged
+	showSAN bool    // true to print the principal variation in SAN, too
 }
 
-func newUCILogger() *uciLogger {
-	return &uciLogger{buf: &bytes.Buffer{}}
+func newUCILogger(out io.Writer) *uciLogger {
+	return &uciLogger{buf: &bytes.Buffer{}, out: out}
 }
 
 func (ul *uciLogger) BeginSearch() {
@@ -54,20 +67,19 @@ func (ul *uciLogger) PrintPV(stats Stats, multiPV int, score int32, pv []Move) {
 	fmt.Fprintf(ul.buf, "info depth %d seldepth %d multipv %d ", stats.Depth, stats.SelDepth, multiPV)
 
 	// Write score.
-	if score > KnownWinScore {
-		fmt.Fprintf(ul.buf, "score mate %d ", (MateScore-score+1)/2)
-	} else if score < KnownLossScore {
-		fmt.Fprintf(ul.buf, "score mate %d ", (MatedScore-score)/2)
+	ul.writeScore(score)
+
+	// Write stats. The time is omitted in the deterministic mode
+	// so the same search gives the same output.
+	if ul.eng.Options.Deterministic {
+		fmt.Fprintf(ul.buf, "nodes %d ", stats.Nodes)
 	} else {
-		fmt.Fprintf(ul.buf, "score cp %d ", score)
+		elapsed := uint64(maxDuration(now.Sub(ul.start), time.Microsecond))
+		nps := stats.Nodes * uint64(time.Second) / elapsed
+		millis := elapsed / uint64(time.Millisecond)
+		fmt.Fprintf(ul.buf, "nodes %d time %d nps %d ", stats.Nodes, millis, nps)
 	}
 
-	// Write stats.
-	elapsed := uint64(maxDuration(now.Sub(ul.start), time.Microsecond))
-	nps := stats.Nodes * uint64(time.Second) / elapsed
-	millis := elapsed / uint64(time.Millisecond)
-	fmt.Fprintf(ul.buf, "nodes %d time %d nps %d ", stats.Nodes, millis, nps)
-
 	// Write principal variation.
 	fmt.Fprintf(ul.buf, "pv")
 	for _, m := range pv {
@@ -75,20 +87,51 @@ func (ul *uciLogger) PrintPV(stats Stats, multiPV int, score int32, pv []Move) {
 	}
 	fmt.Fprintf(ul.buf, "\n")
 
+	// Write principal variation in SAN.
+	if ul.showSAN {
+		fmt.Fprintf(ul.buf, "info string multipv %d san %s\n", multiPV, strings.Join(PVToSAN(ul.eng.Position, pv), " "))
+	}
+
 	ul.flush()
 }
 
+// PrintRootMoves prints the root moves in the all moves mode.
+func (ul *uciLogger) PrintRootMoves(stats Stats, moves []RootMove) {
+	for i, rm := range moves {
+		fmt.Fprintf(ul.buf, "info string rootmove %d %v depth %d ", i+1, rm.Move.UCI(), rm.Depth)
+		ul.writeScore(rm.Score)
+		if rm.Bound != "exact" {
+			fmt.Fprintf(ul.buf, "%s ", rm.Bound)
+		}
+		fmt.Fprintf(ul.buf, "nodes %d share %.1f%%\n", rm.Nodes, rm.Share*100)
+	}
+	ul.flush()
+}
+
+// writeScore writes score in centipawns or moves to mate.
+func (ul *uciLogger) writeScore(score int32) {
+	if score > KnownWinScore {
+		fmt.Fprintf(ul.buf, "score mate %d ", (MateScore-score+1)/2)
+	} else if score < KnownLossScore {
+		fmt.Fprintf(ul.buf, "score mate %d ", (MatedScore-score)/2)
+	} else {
+		fmt.Fprintf(ul.buf, "score cp %d ", score)
+	}
+}
+
 func (ul *uciLogger) CurrMove(depth int, move Move, num int) {
-	if depth > 15 && time.Now().Sub(ul.start) > 10*time.Second {
+	if !ul.eng.Options.Deterministic && depth > 15 && time.Now().Sub(ul.start) > 10*time.Second {
 		fmt.Fprintf(ul.buf, "info depth %d currmove %v currmovenumber %d\n", depth, move.UCI(), num)
 		ul.flush()
 	}
 }
 
-// flush flushes the buf to stdout.
+// flush flushes the buf to the output.
 func (ul *uciLogger) flush() {
-	os.Stdout.Write(ul.buf.Bytes())
-	os.Stdout.Sync()
+	ul.out.Write(ul.buf.Bytes())
+	if f, ok := ul.out.(*os.File); ok {
+		f.Sync()
+	}
 	ul.buf.Reset()
 }
 
@@ -104,6 +147,8 @@ func maxDuration(a, b time.Duration) time.Duration {
 type UCI struct {
 	Engine      *Engine
 	timeControl *TimeControl
+	out         io.Writer // where the UCI output is written
+	maxHashMB   int       // maximum size of the hash table
 
 	// buffer of 1, if empty then the engine is available
 	idle chan struct{}
@@ -113,16 +158,43 @@ type UCI struct {
 	predicted uint64
 	// root moves to search; empty to search all of them.
 	rootMoves []Move
+	// game's time control set by the Time Control option.
+	clock []ClockPeriod
+
+	moveOverhead    time.Duration // time reserved for communication
+	minThinkingTime time.Duration // minimum time to think
+	movesToGo       int32         // moves to go when the GUI doesn't send movestogo
+	lag             *lagMeter     // measures the lag between bestmove and GUI
+	log             *uciLogger    // logs the searches
+	mateSolver      *MateSolver   // solves the mate requested by go mate
+	network         *Network      // network loaded from EvalFile
+	useNNUE         bool          // true to evaluate with network
+	experience      *Experience   // experience from past games, nil if disabled
+	experienceFile  string        // where the experience is saved
+	experienceSize  int           // maximum number of positions in the experience
+	game            []gameSearch  // searches of the current game
+	noFiles         bool          // true to refuse the options that read or write files
 }
 
-func NewUCI() *UCI {
+// NewUCI returns a new UCI instance that writes its output to out.
+func NewUCI(out io.Writer) *UCI {
 	options := Options{}
-	return &UCI{
-		Engine:      NewEngine(nil, newUCILogger(), options),
-		timeControl: nil,
-		idle:        make(chan struct{}, 1),
-		ponder:      make(chan struct{}, 1),
+	ul := newUCILogger(out)
+	uci := &UCI{
+		Engine:         NewEngine(nil, ul, options),
+		timeControl:    nil,
+		out:            out,
+		maxHashMB:      maxHashMB,
+		idle:           make(chan struct{}, 1),
+		ponder:         make(chan struct{}, 1),
+		moveOverhead:   DefaultMoveOverhead,
+		movesToGo:      DefaultMovesToGo,
+		experienceSize: DefaultExperienceSize,
+		lag:            newLagMeter(),
+		log:            ul,
 	}
+	ul.eng = uci.Engine
+	return uci
 }
 
 var reCmd = regexp.MustCompile(`^[[:word:]]+\b`)
@@ -143,9 +215,15 @@ func (uci *UCI) Execute(line string) error {
 	case "isready":
 		return uci.isready(line)
 	case "quit":
-		return errQuit
+		return uci.quit(line)
+	case "q":
+		return uci.quit(line)
+	case "x":
+		return uci.quit(line)
 	case "stop":
 		return uci.stop(line)
+	case "s":
+		return uci.stop(line)
 	case "uci":
 		return uci.uci(line)
 	case "ponderhit":
@@ -166,32 +244,95 @@ func (uci *UCI) Execute(line string) error {
 		return uci.go_(line)
 	case "setoption":
 		return uci.setoption(line)
+	case "stats":
+		return uci.stats(line)
+	case "d", "display":
+		return uci.display(line)
 	default:
 		return fmt.Errorf("unhandled command %s", cmd)
 	}
 }
 
 func (uci *UCI) uci(line string) error {
-	fmt.Printf("id name zurichess %v\n", buildVersion)
-	fmt.Printf("id author Alexandru Moșoi\n")
-	fmt.Printf("\n")
-	fmt.Printf("option name Hash type spin default %v min 1 max 65536\n", DefaultHashTableSizeMB)
-	fmt.Printf("option name MultiPV type spin default %d min 1 max %d\n", uci.Engine.Options.MultiPV, maxMultiPV)
-	fmt.Printf("option name Ponder type check default true\n")
-	fmt.Printf("option name Handicap Level type spin default %d min 0 max %d\n", uci.Engine.Options.HandicapLevel, maxHandicapLevel)
-	fmt.Printf("option name UCI_AnalyseMode type check default false\n")
-	fmt.Println("uciok")
+	fmt.Fprintf(uci.out, "id name zurichess %v\n", buildVersion)
+	fmt.Fprintf(uci.out, "id author Alexandru Moșoi\n")
+	fmt.Fprintf(uci.out, "\n")
+	fmt.Fprintf(uci.out, "option name Hash type spin default %v min 1 max %d\n", DefaultHashTableSizeMB, uci.maxHashMB)
+	fmt.Fprintf(uci.out, "option name MultiPV type spin default %d min 1 max %d\n", uci.Engine.Options.MultiPV, maxMultiPV)
+	fmt.Fprintf(uci.out, "option name Ponder type check default true\n")
+	fmt.Fprintf(uci.out, "option name Handicap Level type spin default %d min 0 max %d\n", uci.Engine.Options.HandicapLevel, maxHandicapLevel)
+	fmt.Fprintf(uci.out, "option name UCI_AnalyseMode type check default false\n")
+	fmt.Fprintf(uci.out, "option name All Moves type check default %v\n", uci.Engine.Options.AllMoves)
+	fmt.Fprintf(uci.out, "option name Contempt type spin default %d min %d max %d\n", uci.Engine.Options.Contempt, -maxContempt, maxContempt)
+	fmt.Fprintf(uci.out, "option name Deterministic type check default %v\n", uci.Engine.Options.Deterministic)
+	fmt.Fprintf(uci.out, "option name Seed type spin default %d min 0 max %d\n", uci.Engine.Options.Seed, maxSeed)
+	fmt.Fprintf(uci.out, "option name Show SAN type check default %v\n", uci.log.showSAN)
+	fmt.Fprintf(uci.out, "option name Time Control type string default <empty>\n")
+	fmt.Fprintf(uci.out, "option name Move Overhead type spin default %d min 0 max %d\n", uci.moveOverhead/time.Millisecond, maxMoveOverhead/time.Millisecond)
+	fmt.Fprintf(uci.out, "option name Minimum Thinking Time type spin default %d min 0 max %d\n", uci.minThinkingTime/time.Millisecond, maxMinThinkingTime/time.Millisecond)
+	fmt.Fprintf(uci.out, "option name Default Moves To Go type spin default %d min 1 max %d\n", uci.movesToGo, maxMovesToGo)
+	fmt.Fprintf(uci.out, "option name Use NNUE type check default %v\n", uci.useNNUE)
+	fmt.Fprintf(uci.out, "option name EvalFile type string default <empty>\n")
+	fmt.Fprintf(uci.out, "option name Experience File type string default <empty>\n")
+	fmt.Fprintf(uci.out, "option name Experience Size type spin default %d min 1 max %d\n", uci.experienceSize, maxExperienceSize)
+	fmt.Fprintln(uci.out, "uciok")
 	return nil
 }
 
 func (uci *UCI) isready(line string) error {
-	fmt.Println("readyok")
+	fmt.Fprintln(uci.out, "readyok")
 	return nil
 }
 
 func (uci *UCI) ucinewgame(line string) error {
+	// Save the experience of the previous game.
+	err := uci.endGame()
 	// Clear the hash at the beginning of each game.
-	GlobalHashTable.Clear()
+	uci.hashTable().Clear()
+	uci.mergeExperience()
+	uci.lag.reset()
+	return err
+}
+
+// quit saves the experience of the current game and quits.
+func (uci *UCI) quit(line string) error {
+	if uci.experience != nil {
+		uci.stop(line)
+		if err := uci.endGame(); err != nil {
+			log.Println(err)
+		}
+	}
+	return errQuit
+}
+
+// stats prints the statistics of the last search.
+// This is an extension to the UCI protocol.
+func (uci *UCI) stats(line string) error {
+	printStats(uci.out, &uci.Engine.Stats)
+	return nil
+}
+
+// hashTable returns the hash table used by the engine.
+func (uci *UCI) hashTable() *HashTable {
+	if uci.Engine.HashTable != nil {
+		return uci.Engine.HashTable
+	}
+	return GlobalHashTable
+}
+
+// setHashTable replaces the hash table used by the engine.
+func (uci *UCI) setHashTable(ht *HashTable) {
+	if uci.Engine.HashTable != nil {
+		uci.Engine.HashTable = ht
+	} else {
+		GlobalHashTable = ht
+	}
+}
+
+// display prints the current position.
+// This is an extension to the UCI protocol.
+func (uci *UCI) display(line string) error {
+	printPosition(uci.out, uci.Engine)
 	return nil
 }
 
@@ -251,14 +392,21 @@ var validGoCommands = map[string]bool{
 	"mate":        true,
 	"movetime":    true,
 	"infinite":    true,
+	"wdelay":      true,
+	"bdelay":      true,
+	"clock":       true,
 }
 
 func (uci *UCI) go_(line string) error {
 	// TODO: Handle panic for `go depth`
 	predicted := uci.predicted == uci.Engine.Position.Zobrist()
 	uci.timeControl = NewTimeControl(uci.Engine.Position, predicted)
+	uci.timeControl.MovesToGo = uci.movesToGo
 	uci.rootMoves = uci.rootMoves[:0]
-	ponder := false
+	uci.mateSolver = nil
+	ponder, mate := false, 0
+	movetime := time.Duration(0)
+	hasTime, hasMovesToGo, hasDelay, hasMode := false, false, false, false
 
 	args := strings.Fields(line)[1:]
 	for i := 0; i < len(args); i++ {
@@ -280,6 +428,7 @@ func (uci *UCI) go_(line string) error {
 			i++
 			t, _ := strconv.Atoi(args[i])
 			uci.timeControl.WTime = time.Duration(t) * time.Millisecond
+			hasTime = true
 		case "winc":
 			i++
 			t, _ := strconv.Atoi(args[i])
@@ -288,6 +437,7 @@ func (uci *UCI) go_(line string) error {
 			i++
 			t, _ := strconv.Atoi(args[i])
 			uci.timeControl.BTime = time.Duration(t) * time.Millisecond
+			hasTime = true
 		case "binc":
 			i++
 			t, _ := strconv.Atoi(args[i])
@@ -296,9 +446,29 @@ func (uci *UCI) go_(line string) error {
 			i++
 			t, _ := strconv.Atoi(args[i])
 			uci.timeControl.MovesToGo = int32(t)
+			hasMovesToGo = true
+		case "wdelay":
+			i++
+			t, _ := strconv.Atoi(args[i])
+			uci.timeControl.WDelay = time.Duration(t) * time.Millisecond
+			hasDelay = true
+		case "bdelay":
+			i++
+			t, _ := strconv.Atoi(args[i])
+			uci.timeControl.BDelay = time.Duration(t) * time.Millisecond
+			hasDelay = true
+		case "clock":
+			i++
+			mode, err := ParseClockMode(args[i])
+			if err != nil {
+				return err
+			}
+			uci.timeControl.Mode = mode
+			hasMode = true
 		case "movetime":
 			i++
 			t, _ := strconv.Atoi(args[i])
+			movetime = time.Duration(t) * time.Millisecond
 			uci.timeControl.WTime = time.Duration(t) * time.Millisecond
 			uci.timeControl.WInc = 0
 			uci.timeControl.BTime = time.Duration(t) * time.Millisecond
@@ -308,14 +478,65 @@ func (uci *UCI) go_(line string) error {
 			i++
 			d, _ := strconv.Atoi(args[i])
 			uci.timeControl.Depth = int32(d)
-		case "nodes", "mate":
-			log.Println(args[i], "not implemented. Ignoring")
+		case "nodes":
 			i++
+			n, _ := strconv.ParseUint(args[i], 10, 64)
+			uci.timeControl.Nodes = n
+		case "mate":
+			i++
+			mate, _ = strconv.Atoi(args[i])
 		default:
 			return fmt.Errorf("invalid go command %s", args[i])
 		}
 	}
 
+	if mate > 0 {
+		// Prove the mate instead of searching.
+		uci.mateSolver = &MateSolver{MaxNodes: maxMateNodes}
+		if n := uci.timeControl.Nodes; n != 0 && n < maxMateNodes {
+			uci.mateSolver.MaxNodes = n
+		}
+		uci.idle <- struct{}{}
+		go uci.solveMate(uci.mateSolver, mate, movetime)
+		return nil
+	}
+
+	// Complete the clock from the Time Control option.
+	p, movesToGo, ok := CurrentClockPeriod(uci.clock, uci.Engine.Position.FullmoveCounter())
+	if ok && hasTime {
+		if !hasMode {
+			uci.timeControl.Mode = p.Mode
+		}
+		if !hasDelay {
+			uci.timeControl.WDelay, uci.timeControl.BDelay = p.Delay, p.Delay
+		}
+		if !hasMovesToGo && movesToGo != 0 {
+			uci.timeControl.MovesToGo = movesToGo
+		}
+	}
+
+	// Adapt the overhead to the measured lag.
+	tc := uci.timeControl
+	if hasTime {
+		us := uci.Engine.Position.Us()
+		remaining, inc, delay := tc.WTime, tc.WInc, tc.WDelay
+		if us == Black {
+			remaining, inc, delay = tc.BTime, tc.BInc, tc.BDelay
+		}
+		if lag := uci.lag.begin(uci.Engine.Position.Ply, tc.Mode, remaining, inc, delay); lag > uci.moveOverhead {
+			log.Printf("lag %v exceeds move overhead %v", lag, uci.moveOverhead)
+		}
+	} else {
+		uci.lag.skip()
+	}
+	tc.Overhead = uci.lag.overhead(uci.moveOverhead)
+	tc.MinThinkingTime = uci.minThinkingTime
+	if uci.Engine.Options.Deterministic {
+		// The measured lag would change the node limits.
+		tc.Overhead = uci.moveOverhead
+		tc.Deterministic = true
+	}
+
 	if ponder {
 		// Ponder was requested, so fill the channel.
 		// Next write to uci.ponder will block.
@@ -339,6 +560,9 @@ func (uci *UCI) stop(line string) error {
 	if uci.timeControl != nil {
 		uci.timeControl.Stop()
 	}
+	if uci.mateSolver != nil {
+		uci.mateSolver.Stop()
+	}
 	// No longer pondering.
 	select {
 	case <-uci.ponder:
@@ -354,7 +578,7 @@ func (uci *UCI) stop(line string) error {
 // play starts the negine.
 // Should run in its own separate goroutine.
 func (uci *UCI) play() {
-	_, moves := uci.Engine.PlayMoves(uci.timeControl, uci.rootMoves)
+	score, moves := uci.Engine.PlayMoves(uci.timeControl, uci.rootMoves)
 
 	if len(moves) >= 2 {
 		uci.Engine.Position.DoMove(moves[0])
@@ -370,14 +594,18 @@ func (uci *UCI) play() {
 	uci.ponder <- struct{}{}
 	<-uci.ponder
 
-	if len(moves) == 0 {
-		fmt.Printf("bestmove (none)\n")
-	} else if len(moves) == 1 {
-		fmt.Printf("bestmove %v\n", moves[0].UCI())
-	} else {
-		fmt.Printf("bestmove %v ponder %v\n", moves[0].UCI(), moves[1].UCI())
+	// After a ponder miss the searched position was not played.
+	if !uci.timeControl.Pondering() {
+		uci.recordSearch(score, moves)
 	}
 
+	if uci.Engine.Options.AnalyseMode {
+		printStats(uci.out, &uci.Engine.Stats)
+	}
+
+	uci.lag.end()
+	uci.printBestMove(moves)
+
 	// Marks the engine as idle.
 	// If the engine is made idle before best move is shown
 	// then sometimes (at very high rate of commands position / go)
@@ -386,8 +614,63 @@ func (uci *UCI) play() {
 	<-uci.idle
 }
 
+// printBestMove prints the best move and the ponder move from moves.
+func (uci *UCI) printBestMove(moves []Move) {
+	if len(moves) == 0 {
+		fmt.Fprintf(uci.out, "bestmove (none)\n")
+	} else if len(moves) == 1 {
+		fmt.Fprintf(uci.out, "bestmove %v\n", moves[0].UCI())
+	} else {
+		fmt.Fprintf(uci.out, "bestmove %v ponder %v\n", moves[0].UCI(), moves[1].UCI())
+	}
+}
+
+// solveMate searches for a mate in at most n moves,
+// stopping after movetime if movetime is not zero.
+// Should run in its own separate goroutine.
+func (uci *UCI) solveMate(ms *MateSolver, n int, movetime time.Duration) {
+	var timer *time.Timer
+	if movetime != 0 {
+		timer = time.AfterFunc(movetime, ms.Stop)
+	}
+	uci.Engine.Log.BeginSearch()
+	sol := ms.Solve(uci.Engine.Position, n)
+	if timer != nil {
+		timer.Stop()
+	}
+	if sol == nil {
+		// Reply with the move of a short search so the game can continue.
+		fmt.Fprintf(uci.out, "info string no mate in %d found\n", n)
+		tc := NewFixedDepthTimeControl(uci.Engine.Position, mateFallbackDepth)
+		tc.Start(false)
+		_, moves := uci.Engine.PlayMoves(tc, nil)
+		uci.printBestMove(moves)
+		<-uci.idle
+		return
+	}
+
+	pv := sol.PV()
+	plies := int32(2*sol.Moves() - 1)
+	stats := Stats{Depth: plies, SelDepth: plies, Nodes: ms.Nodes}
+	uci.Engine.Log.PrintPV(stats, 1, MateScore-plies, pv)
+	uci.printBestMove(pv)
+	<-uci.idle
+}
+
 var reOption = regexp.MustCompile(`^setoption\s+name\s+(.+?)(\s+value\s+(.*))?$`)
 
+// setNetwork sets the evaluation network of the engine.
+func (uci *UCI) setNetwork() error {
+	uci.Engine.Network = nil
+	if uci.useNNUE {
+		if uci.network == nil {
+			return fmt.Errorf("Use NNUE requires an EvalFile")
+		}
+		uci.Engine.Network = uci.network
+	}
+	return nil
+}
+
 func (uci *UCI) setoption(line string) error {
 	option := reOption.FindStringSubmatch(line)
 	if option == nil {
@@ -400,7 +683,7 @@ func (uci *UCI) setoption(line string) error {
 	}
 	switch option[1] {
 	case "Clear Hash":
-		GlobalHashTable.Clear()
+		uci.hashTable().Clear()
 		return nil
 	}
 
@@ -408,6 +691,9 @@ func (uci *UCI) setoption(line string) error {
 	if len(option) < 3 {
 		return fmt.Errorf("missing setoption value")
 	}
+	if uci.noFiles && (option[1] == "EvalFile" || option[1] == "Experience File") {
+		return fmt.Errorf("option %s is disabled", option[1])
+	}
 	switch option[1] {
 	case "UCI_AnalyseMode":
 		if mode, err := strconv.ParseBool(option[3]); err != nil {
@@ -416,11 +702,20 @@ func (uci *UCI) setoption(line string) error {
 			uci.Engine.Options.AnalyseMode = mode
 		}
 		return nil
+	case "All Moves":
+		if allMoves, err := strconv.ParseBool(option[3]); err != nil {
+			return err
+		} else {
+			uci.Engine.Options.AllMoves = allMoves
+		}
+		return nil
 	case "Hash":
 		if hashSizeMB, err := strconv.ParseInt(option[3], 10, 64); err != nil {
 			return err
+		} else if 1 <= hashSizeMB && hashSizeMB <= int64(uci.maxHashMB) {
+			uci.setHashTable(NewHashTable(int(hashSizeMB)))
 		} else {
-			GlobalHashTable = NewHashTable(int(hashSizeMB))
+			return fmt.Errorf("Hash must be between 1 and %d", uci.maxHashMB)
 		}
 		return nil
 	case "MultiPV":
@@ -441,8 +736,101 @@ func (uci *UCI) setoption(line string) error {
 			return fmt.Errorf("Handicap Level must be between 0 and %d", maxHandicapLevel)
 		}
 		return nil
+	case "Contempt":
+		if contempt, err := strconv.ParseInt(option[3], 10, 64); err != nil {
+			return err
+		} else if -maxContempt <= contempt && contempt <= maxContempt {
+			uci.Engine.Options.Contempt = int32(contempt)
+		} else {
+			return fmt.Errorf("Contempt must be between %d and %d", -maxContempt, maxContempt)
+		}
+		return nil
+	case "Deterministic":
+		if deterministic, err := strconv.ParseBool(option[3]); err != nil {
+			return err
+		} else {
+			uci.Engine.Options.Deterministic = deterministic
+		}
+		return nil
+	case "Seed":
+		if seed, err := strconv.ParseInt(option[3], 10, 64); err != nil {
+			return err
+		} else if 0 <= seed && seed <= maxSeed {
+			uci.Engine.Options.Seed = seed
+		} else {
+			return fmt.Errorf("Seed must be between 0 and %d", maxSeed)
+		}
+		return nil
 	case "Ponder":
 		return nil
+	case "Show SAN":
+		if showSAN, err := strconv.ParseBool(option[3]); err != nil {
+			return err
+		} else {
+			uci.log.showSAN = showSAN
+		}
+		return nil
+	case "Move Overhead":
+		if overhead, err := strconv.ParseInt(option[3], 10, 64); err != nil {
+			return err
+		} else if d := time.Duration(overhead) * time.Millisecond; 0 <= d && d <= maxMoveOverhead {
+			uci.moveOverhead = d
+		} else {
+			return fmt.Errorf("Move Overhead must be between 0 and %d", maxMoveOverhead/time.Millisecond)
+		}
+		return nil
+	case "Minimum Thinking Time":
+		if minThinkingTime, err := strconv.ParseInt(option[3], 10, 64); err != nil {
+			return err
+		} else if d := time.Duration(minThinkingTime) * time.Millisecond; 0 <= d && d <= maxMinThinkingTime {
+			uci.minThinkingTime = d
+		} else {
+			return fmt.Errorf("Minimum Thinking Time must be between 0 and %d", maxMinThinkingTime/time.Millisecond)
+		}
+		return nil
+	case "Default Moves To Go":
+		if movesToGo, err := strconv.ParseInt(option[3], 10, 64); err != nil {
+			return err
+		} else if 1 <= movesToGo && movesToGo <= maxMovesToGo {
+			uci.movesToGo = int32(movesToGo)
+		} else {
+			return fmt.Errorf("Default Moves To Go must be between 1 and %d", maxMovesToGo)
+		}
+		return nil
+	case "Time Control":
+		if clock, err := ParseTimeControl(strings.TrimPrefix(option[3], "<empty>")); err != nil {
+			return err
+		} else {
+			uci.clock = clock
+		}
+		return nil
+	case "Use NNUE":
+		if useNNUE, err := strconv.ParseBool(option[3]); err != nil {
+			return err
+		} else {
+			uci.useNNUE = useNNUE
+		}
+		return uci.setNetwork()
+	case "EvalFile":
+		if name := strings.TrimPrefix(option[3], "<empty>"); name == "" {
+			uci.network = nil
+		} else if network, err := ReadNetworkFile(name); err != nil {
+			return err
+		} else {
+			uci.network = network
+		}
+		return uci.setNetwork()
+	case "Experience File":
+		return uci.setExperienceFile(strings.TrimPrefix(option[3], "<empty>"))
+	case "Experience Size":
+		if size, err := strconv.ParseInt(option[3], 10, 64); err != nil {
+			return err
+		} else if 1 <= size && size <= maxExperienceSize {
+			uci.experienceSize = int(size)
+		} else {
+			return fmt.Errorf("Experience Size must be between 1 and %d", maxExperienceSize)
+		}
+		return nil
 	default:
 		return fmt.Errorf("unhandled option %s", option[1])
 	}
@@ -0,0 +1,134 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "bitbucket.org/zurichess/board"
+	. "bitbucket.org/zurichess/zurichess/engine"
+)
+
+func TestGoMate(t *testing.T) {
+	data := []struct {
+		fen  string
+		mate string
+		want []string
+	}{
+		{"r4rk1/pppb1ppp/2n1p3/3p4/4nPBq/1PP5/P1QPP2P/RNBK2NR b - - 2 11", "3", []string{"score mate 3 ", "bestmove e4f2 ponder d1e1\n"}},
+		{"6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "2", []string{"score mate 1 ", "pv a1a8\n", "bestmove a1a8\n"}},
+		{"r4rk1/pppb1ppp/2n1p3/3p4/4nPBq/1PP5/P1QPP2P/RNBK2NR b - - 2 11", "2", []string{"no mate in 2 found", "bestmove "}},
+		{"r4rk1/pppb1ppp/2n1p3/3p4/4nPBq/1PP5/P1QPP2P/RNBK2NR b - - 2 11", "3 nodes 10", []string{"no mate in 3 found", "bestmove "}},
+	}
+	for _, d := range data {
+		buf := &bytes.Buffer{}
+		uci := NewUCI(buf)
+		for _, line := range []string{"position fen " + d.fen, "go mate " + d.mate} {
+			if err := uci.Execute(line); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// Wait for the solver to finish without stopping it.
+		uci.idle <- struct{}{}
+		<-uci.idle
+
+		for _, w := range d.want {
+			if out := buf.String(); !strings.Contains(out, w) {
+				t.Errorf("%s: missing %q in\n%s", d.fen, w, out)
+			}
+		}
+		if out := buf.String(); strings.Contains(out, "bestmove (none)") {
+			t.Errorf("%s: got no best move in\n%s", d.fen, out)
+		}
+	}
+}
+
+func TestExperienceFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "experience")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "experience.bin")
+
+	// Black is mated after the search.
+	const fen = "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1"
+	uci := NewUCI(&bytes.Buffer{})
+	uci.Engine.HashTable = NewHashTable(1)
+	for _, line := range []string{
+		"setoption name Experience File value " + name,
+		"ucinewgame",
+		"position fen " + fen,
+		"go depth 4",
+		"isready",
+	} {
+		if err := uci.Execute(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	uci.idle <- struct{}{}
+	<-uci.idle
+	for _, line := range []string{"position fen " + fen + " moves a1a8", "ucinewgame"} {
+		if err := uci.Execute(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	x, err := ReadExperienceFile(name, DefaultExperienceSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pos, _ := PositionFromFEN(fen)
+	e, ok := x.Get(pos.Zobrist())
+	if !ok || e.Move.UCI() != "a1a8" || e.Wins != 1 || e.Games() != 1 {
+		t.Errorf("got experience %+v, ok %v, wanted a won game with a1a8", e, ok)
+	}
+}
+
+func TestExperiencePonder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "experience")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Only the searches ended by ponderhit are recorded.
+	const fen = "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1"
+	for i, end := range []string{"ponderhit", "stop"} {
+		name := filepath.Join(dir, fmt.Sprintf("experience%d.bin", i))
+		uci := NewUCI(&bytes.Buffer{})
+		uci.Engine.HashTable = NewHashTable(1)
+		for _, line := range []string{
+			"setoption name Experience File value " + name,
+			"ucinewgame",
+			"position fen " + fen,
+			"go ponder depth 4",
+			end,
+		} {
+			if err := uci.Execute(line); err != nil {
+				t.Fatal(err)
+			}
+		}
+		uci.idle <- struct{}{}
+		<-uci.idle
+		if err := uci.Execute("ucinewgame"); err != nil {
+			t.Fatal(err)
+		}
+
+		x, err := ReadExperienceFile(name, DefaultExperienceSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := end == "ponderhit"; (x.Len() != 0) != want {
+			t.Errorf("%s: got %d positions, wanted recorded %v", end, x.Len(), want)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// websocket.go implements a bridge that serves UCI over WebSocket
+// for GUIs running in a browser which cannot spawn the engine.
+//
+// Every connection gets its own UCI instance and hash table.
+// Browsers let any web page open WebSocket connections, so requests
+// from other origins are rejected unless their origin is allowed
+// and the options which read or write files are disabled.
+// Each text message received is one or more UCI commands separated
+// by new lines and each line of output is sent as one text message.
+
+package main
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	. "bitbucket.org/zurichess/zurichess/engine"
+	"golang.org/x/net/websocket"
+)
+
+const maxMessageSize = 64 << 10
+
+// lineWriter sends every line written as a websocket text message.
+// It is safe for concurrent use.
+type lineWriter struct {
+	mu   sync.Mutex
+	conn *websocket.Conn
+	buf  []byte // incomplete line
+}
+
+func (lw *lineWriter) Write(p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+
+	lw.buf = append(lw.buf, p...)
+	for {
+		i := bytes.IndexByte(lw.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		line := string(lw.buf[:i])
+		lw.buf = lw.buf[i+1:]
+		if err := websocket.Message.Send(lw.conn, line); err != nil {
+			lw.buf = lw.buf[:0]
+			return 0, err
+		}
+	}
+}
+
+// bridge serves UCI over WebSocket.
+type bridge struct {
+	token     string        // token required to connect, empty to allow everyone
+	origins   []string      // origins allowed to connect from a browser
+	conns     chan struct{} // one element for every open connection
+	hashMB    int           // initial size of the hash table of each connection
+	maxHashMB int           // maximum size of the hash table of each connection
+}
+
+func newBridge(token string, origins []string, maxConns, hashMB, maxHashMB int) *bridge {
+	return &bridge{
+		token:     token,
+		origins:   origins,
+		conns:     make(chan struct{}, maxConns),
+		hashMB:    hashMB,
+		maxHashMB: maxHashMB,
+	}
+}
+
+// authorized returns true if r carries the bridge's token either
+// as the token query parameter or as a bearer token.
+// Browsers cannot set headers on WebSocket requests so
+// they must use the query parameter.
+func (b *bridge) authorized(r *http.Request) bool {
+	if b.token == "" {
+		return true
+	}
+	token := r.URL.Query().Get("token")
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(b.token)) == 1
+}
+
+// checkOrigin rejects the requests from browsers unless
+// their origin is allowed. Requests without an Origin header
+// don't come from browsers and are accepted.
+func (b *bridge) checkOrigin(config *websocket.Config, r *http.Request) error {
+	origin, err := websocket.Origin(config, r)
+	if err != nil || origin == nil {
+		return err
+	}
+	config.Origin = origin
+	for _, o := range b.origins {
+		if u, err := url.Parse(o); err == nil && strings.EqualFold(u.Scheme, origin.Scheme) && strings.EqualFold(u.Host, origin.Host) {
+			return nil
+		}
+	}
+	return fmt.Errorf("origin %s is not allowed", origin)
+}
+
+func (b *bridge) handler() http.Handler {
+	ws := websocket.Server{Handler: b.serveConn, Handshake: b.checkOrigin}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/uci", func(w http.ResponseWriter, r *http.Request) {
+		if !b.authorized(r) {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		select {
+		case b.conns <- struct{}{}:
+			defer func() { <-b.conns }()
+		default:
+			http.Error(w, "too many connections", http.StatusServiceUnavailable)
+			return
+		}
+		ws.ServeHTTP(w, r)
+	})
+	return mux
+}
+
+// serveConn executes the UCI commands received on conn until
+// the connection is closed or the client quits.
+func (b *bridge) serveConn(conn *websocket.Conn) {
+	conn.MaxPayloadBytes = maxMessageSize
+	out := &lineWriter{conn: conn}
+	uci := NewUCI(out)
+	uci.Engine.HashTable = NewHashTable(b.hashMB)
+	uci.maxHashMB = b.maxHashMB
+	uci.noFiles = true
+
+	// Stop any search before the connection is closed.
+	defer uci.Execute("stop")
+
+	for {
+		var msg string
+		if err := websocket.Message.Receive(conn, &msg); err != nil {
+			if err != io.EOF {
+				log.Println(err)
+			}
+			return
+		}
+		for _, line := range strings.Split(msg, "\n") {
+			if err := uci.Execute(line); err == errQuit {
+				return
+			} else if err != nil {
+				fmt.Fprintf(out, "info string %v\n", err)
+			}
+		}
+	}
+}
+
+func websocketCommand(args []string) error {
+	fs := flag.NewFlagSet("websocket", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:8081", "address to listen on")
+	token := fs.String("token", "", "token required to connect, empty to allow everyone")
+	origins := fs.String("origins", "", "comma separated origins, e.g. https://example.com, allowed to connect from a browser")
+	maxConns := fs.Int("maxconns", 4, "maximum number of concurrent connections")
+	hashMB := fs.Int("hash", 16, "initial transposition table size in MB for each connection")
+	maxHashMB := fs.Int("maxhash", 256, "maximum transposition table size in MB for each connection")
+	fs.Parse(args)
+	if *maxConns < 1 {
+		return errors.New("at least one connection is required")
+	}
+	if *hashMB < 1 || *hashMB > *maxHashMB {
+		return fmt.Errorf("hash must be between 1 and %d", *maxHashMB)
+	}
+
+	var allowed []string
+	for _, o := range strings.Split(*origins, ",") {
+		if o = strings.TrimSpace(o); o != "" {
+			allowed = append(allowed, o)
+		}
+	}
+
+	b := newBridge(*token, allowed, *maxConns, *hashMB, *maxHashMB)
+	log.Printf("serving UCI on ws://%s/uci with at most %d connections", *addr, *maxConns)
+	return http.ListenAndServe(*addr, b.handler())
+}
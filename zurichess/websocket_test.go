@@ -0,0 +1,161 @@
+// Copyright 2014-2017 The Zurichess Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "bitbucket.org/zurichess/board"
+	. "bitbucket.org/zurichess/zurichess/engine"
+	"golang.org/x/net/websocket"
+)
+
+func newTestBridge(t *testing.T, token string, maxConns int) string {
+	ts := httptest.NewServer(newBridge(token, []string{"http://localhost"}, maxConns, 1, 4).handler())
+	t.Cleanup(ts.Close)
+	return "ws" + strings.TrimPrefix(ts.URL, "http") + "/uci"
+}
+
+func dialBridge(t *testing.T, url string) *websocket.Conn {
+	conn, err := websocket.Dial(url, "", "http://localhost/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// expect reads messages from conn until one starts with prefix.
+func expect(t *testing.T, conn *websocket.Conn, prefix string) string {
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	for {
+		var msg string
+		if err := websocket.Message.Receive(conn, &msg); err != nil {
+			t.Fatalf("waiting for %s: %v", prefix, err)
+		}
+		if strings.HasPrefix(msg, prefix) {
+			return msg
+		}
+	}
+}
+
+func send(t *testing.T, conn *websocket.Conn, msg string) {
+	if err := websocket.Message.Send(conn, msg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBridgePlayGame(t *testing.T) {
+	conn := dialBridge(t, newTestBridge(t, "", 1))
+	send(t, conn, "uci")
+	expect(t, conn, "uciok")
+	send(t, conn, "setoption name Hash value 2\nucinewgame\nisready")
+	expect(t, conn, "readyok")
+
+	pos, _ := PositionFromFEN(FENStartPos)
+	var moves []string
+	for ply := 0; ply < 400; ply++ {
+		if !pos.HasLegalMoves() || pos.InsufficientMaterial() ||
+			pos.FiftyMoveRule() || pos.ThreeFoldRepetition() >= 3 {
+			break
+		}
+
+		send(t, conn, "position startpos moves "+strings.Join(moves, " "))
+		send(t, conn, "go depth 2")
+		fields := strings.Fields(expect(t, conn, "bestmove"))
+		m, err := pos.UCIToMove(fields[1])
+		if err != nil || !IsLegal(pos, m) {
+			t.Fatalf("after %v got illegal bestmove %s", moves, fields[1])
+		}
+		pos.DoMove(m)
+		moves = append(moves, fields[1])
+	}
+
+	if pos.HasLegalMoves() && !pos.InsufficientMaterial() &&
+		!pos.FiftyMoveRule() && pos.ThreeFoldRepetition() < 3 {
+		t.Logf("game adjudicated after %d moves", len(moves))
+	}
+	send(t, conn, "quit")
+}
+
+func TestBridgeErrors(t *testing.T) {
+	conn := dialBridge(t, newTestBridge(t, "", 1))
+	send(t, conn, "setoption name Hash value 1000")
+	if msg := expect(t, conn, "info string"); !strings.Contains(msg, "Hash") {
+		t.Errorf("got %q, wanted hash error", msg)
+	}
+}
+
+func TestBridgeToken(t *testing.T) {
+	url := newTestBridge(t, "secret", 1)
+	if _, err := websocket.Dial(url, "", "http://localhost/"); err == nil {
+		t.Errorf("connected without token")
+	}
+	if _, err := websocket.Dial(url+"?token=wrong", "", "http://localhost/"); err == nil {
+		t.Errorf("connected with wrong token")
+	}
+	conn := dialBridge(t, url+"?token=secret")
+	send(t, conn, "isready")
+	expect(t, conn, "readyok")
+}
+
+func TestBridgeMaxConns(t *testing.T) {
+	url := newTestBridge(t, "", 1)
+	conn := dialBridge(t, url)
+	send(t, conn, "isready")
+	expect(t, conn, "readyok")
+	if _, err := websocket.Dial(url, "", "http://localhost/"); err == nil {
+		t.Errorf("connected above the connection limit")
+	}
+
+	// The connection is released when the client quits.
+	send(t, conn, "quit")
+	for i := 0; ; i++ {
+		c, err := websocket.Dial(url, "", "http://localhost/")
+		if err == nil {
+			c.Close()
+			break
+		}
+		if i == 100 {
+			t.Fatalf("connection not released: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestBridgeOrigin(t *testing.T) {
+	url := newTestBridge(t, "", 4)
+	if _, err := websocket.Dial(url, "", "http://evil.example/"); err == nil {
+		t.Errorf("connected from a foreign origin")
+	}
+	conn := dialBridge(t, url)
+	send(t, conn, "isready")
+	expect(t, conn, "readyok")
+
+	// Requests without Origin don't come from browsers.
+	b := newBridge("", nil, 1, 1, 1)
+	r := httptest.NewRequest("GET", "/uci", nil)
+	if err := b.checkOrigin(&websocket.Config{Version: websocket.ProtocolVersionHybi13}, r); err != nil {
+		t.Errorf("got %v for a request without origin", err)
+	}
+	r.Header = http.Header{"Origin": {"http://localhost"}}
+	if err := b.checkOrigin(&websocket.Config{Version: websocket.ProtocolVersionHybi13}, r); err == nil {
+		t.Errorf("accepted an origin which is not allowed")
+	}
+}
+
+func TestBridgeFileOptions(t *testing.T) {
+	conn := dialBridge(t, newTestBridge(t, "", 1))
+	for _, option := range []string{"EvalFile", "Experience File"} {
+		send(t, conn, "setoption name "+option+" value /etc/passwd")
+		if msg := expect(t, conn, "info string"); !strings.Contains(msg, "disabled") {
+			t.Errorf("got %q, wanted %s disabled", msg, option)
+		}
+	}
+}